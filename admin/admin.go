@@ -0,0 +1,220 @@
+// Package admin provides operational tooling for the notification Kafka cluster,
+// such as consumer group offset management.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/config"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+	"github.com/dawit-go/notification-kafka-lib/producer"
+	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+)
+
+// ErrGroupActive is returned when an operation that rewrites a consumer group's
+// offsets is attempted while the group still has active members.
+var ErrGroupActive = errors.New("consumer group has active members")
+
+// OffsetPosition is the position a consumer group's offsets are reset to.
+// Use OffsetEarliest, OffsetLatest, or Offset for a specific value.
+type OffsetPosition int64
+
+const (
+	// OffsetEarliest resets to the oldest offset still retained by the broker.
+	OffsetEarliest = OffsetPosition(sarama.OffsetOldest)
+	// OffsetLatest resets to the high-water mark, skipping all existing messages.
+	OffsetLatest = OffsetPosition(sarama.OffsetNewest)
+)
+
+// Offset returns an OffsetPosition for a specific offset value.
+func Offset(offset int64) OffsetPosition {
+	return OffsetPosition(offset)
+}
+
+// NotificationAdmin wraps a Sarama client and ClusterAdmin to perform administrative
+// operations for the notification service.
+type NotificationAdmin struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	logger producer.Logger
+	config config.KafkaConfig
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewNotificationAdmin creates a new NotificationAdmin using the provided KafkaConfig
+// and logger. A nil logger is replaced with a no-op logger.
+//
+// Returns an error if the brokers list is empty or if the admin client fails to initialize.
+func NewNotificationAdmin(cfg config.KafkaConfig, logger utils.Logger) (*NotificationAdmin, error) {
+	brokers, err := kafkautil.Brokers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaConfig := kafkautil.NewSaramaConfig(cfg)
+	kafkaConfig.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(brokers, kafkaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	clusterAdmin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka cluster admin: %w", err)
+	}
+
+	var log producer.Logger = producer.NopLogger()
+	if logger != nil {
+		log = logger
+	}
+
+	return &NotificationAdmin{
+		client: client,
+		admin:  clusterAdmin,
+		logger: log,
+		config: cfg,
+	}, nil
+}
+
+// ResetOffsets resets the committed offsets of the configured consumer group on every
+// partition of topic to the given position. The configured TopicPrefix is prepended
+// to topic.
+//
+// Returns ErrGroupActive if the group has active members, since rewriting offsets
+// under a live consumer would be overwritten or cause messages to be skipped, or an
+// error if the offsets cannot be resolved or committed.
+func (na *NotificationAdmin) ResetOffsets(ctx context.Context, topic string, to OffsetPosition) error {
+	group := na.config.ConsumerGroup
+	topic = na.config.Topic(topic)
+
+	if err := na.ensureGroupInactive(group); err != nil {
+		return err
+	}
+
+	partitions, err := na.client.Partitions(topic)
+	if err != nil {
+		return fmt.Errorf("failed to list partitions for %s: %w", topic, err)
+	}
+
+	targets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if targets[partition], err = na.resolveOffset(topic, partition, to); err != nil {
+			return err
+		}
+	}
+
+	offsetManager, err := sarama.NewOffsetManagerFromClient(group, na.client)
+	if err != nil {
+		return fmt.Errorf("failed to create offset manager for group %s: %w", group, err)
+	}
+
+	managers := make([]sarama.PartitionOffsetManager, 0, len(targets))
+	for partition, offset := range targets {
+		pom, err := offsetManager.ManagePartition(topic, partition)
+		if err != nil {
+			na.closeOffsetManager(offsetManager, group)
+			return fmt.Errorf("failed to manage offsets for %s/%d: %w", topic, partition, err)
+		}
+		pom.ResetOffset(offset, "")
+		managers = append(managers, pom)
+	}
+
+	// Commit flushes the reset offsets; closing the offset manager afterwards retries
+	// any failed flush and releases the partition managers so their errors can be read.
+	offsetManager.Commit()
+	na.closeOffsetManager(offsetManager, group)
+
+	var commitErrs []error
+	for _, pom := range managers {
+		if err := pom.Close(); err != nil {
+			commitErrs = append(commitErrs, err)
+		}
+	}
+	if len(commitErrs) > 0 {
+		return fmt.Errorf("failed to commit offsets for group %s on %s: %w", group, topic, errors.Join(commitErrs...))
+	}
+
+	na.logger.Infof("Consumer group offsets reset | Group: %s | Topic: %s | Partitions: %d", group, topic, len(targets))
+	return nil
+}
+
+// closeOffsetManager closes offsetManager, logging any error.
+func (na *NotificationAdmin) closeOffsetManager(offsetManager sarama.OffsetManager, group string) {
+	if err := offsetManager.Close(); err != nil {
+		na.logger.Errorf("Error closing offset manager for group %s: %v", group, err)
+	}
+}
+
+// ensureGroupInactive verifies that group has no active members.
+//
+// Returns ErrGroupActive if it does, or an error if the group cannot be described.
+func (na *NotificationAdmin) ensureGroupInactive(group string) error {
+	groups, err := na.admin.DescribeConsumerGroups([]string{group})
+	if err != nil {
+		return fmt.Errorf("failed to describe consumer group %s: %w", group, err)
+	}
+
+	for _, g := range groups {
+		if g.Err != sarama.ErrNoError {
+			return fmt.Errorf("failed to describe consumer group %s: %w", group, g.Err)
+		}
+		if len(g.Members) > 0 {
+			return fmt.Errorf("%w: %s has %d members in state %s", ErrGroupActive, group, len(g.Members), g.State)
+		}
+	}
+	return nil
+}
+
+// resolveOffset converts an OffsetPosition into a concrete offset for topic/partition.
+//
+// Returns an error if a specific offset lies outside the partition's retained range.
+func (na *NotificationAdmin) resolveOffset(topic string, partition int32, to OffsetPosition) (int64, error) {
+	oldest, err := na.client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest offset for %s/%d: %w", topic, partition, err)
+	}
+	newest, err := na.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get high-water mark for %s/%d: %w", topic, partition, err)
+	}
+
+	switch to {
+	case OffsetEarliest:
+		return oldest, nil
+	case OffsetLatest:
+		return newest, nil
+	}
+
+	offset := int64(to)
+	if offset < oldest || offset > newest {
+		return 0, fmt.Errorf("offset %d is outside the retained range [%d, %d] for %s/%d", offset, oldest, newest, topic, partition)
+	}
+	return offset, nil
+}
+
+// Close closes the underlying cluster admin and client.
+// It is safe to call multiple times; subsequent calls have no effect.
+func (na *NotificationAdmin) Close() {
+	na.mu.Lock()
+	defer na.mu.Unlock()
+
+	if na.closed {
+		return
+	}
+
+	na.closed = true
+	// Closing the cluster admin also closes the client it was created from.
+	if err := na.admin.Close(); err != nil {
+		na.logger.Errorf("Error closing Kafka cluster admin: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// TopicSpec describes a topic that EnsureTopics creates if it is missing.
+type TopicSpec struct {
+	Name              string             // Topic name, without the configured TopicPrefix
+	Partitions        int32              // Number of partitions
+	ReplicationFactor int16              // Number of replicas per partition
+	ConfigEntries     map[string]*string // Optional topic-level configuration (e.g., cleanup.policy)
+}
+
+// DefaultTopicSpecs returns specs for the SMS, email, in-app, and push topics from
+// the configuration, using the configured partition count and replication factor.
+func (na *NotificationAdmin) DefaultTopicSpecs() []TopicSpec {
+	topics := []string{na.config.SMSTopic, na.config.EmailTopic, na.config.InAppTopic, na.config.PushTopic}
+
+	specs := make([]TopicSpec, 0, len(topics))
+	for _, topic := range topics {
+		if topic == "" {
+			continue
+		}
+		specs = append(specs, TopicSpec{
+			Name:              topic,
+			Partitions:        int32(na.config.TopicPartitions),
+			ReplicationFactor: int16(na.config.TopicReplication),
+		})
+	}
+	return specs
+}
+
+// EnsureTopics creates every topic in specs that does not already exist. Existing
+// topics are left untouched, so it is safe to call on every startup. The configured
+// TopicPrefix is prepended to each name.
+//
+// Returns an error if the topic list cannot be fetched or a topic cannot be created.
+func (na *NotificationAdmin) EnsureTopics(ctx context.Context, specs []TopicSpec) error {
+	existing, err := na.admin.ListTopics()
+	if err != nil {
+		return fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	for _, spec := range specs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := na.config.Topic(spec.Name)
+		if _, ok := existing[name]; ok {
+			continue
+		}
+
+		detail := &sarama.TopicDetail{
+			NumPartitions:     spec.Partitions,
+			ReplicationFactor: spec.ReplicationFactor,
+			ConfigEntries:     spec.ConfigEntries,
+		}
+		if err := na.admin.CreateTopic(name, detail, false); err != nil {
+			if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+				continue
+			}
+			return fmt.Errorf("failed to create topic %s: %w", name, err)
+		}
+
+		na.logger.Infof("Kafka topic created | Topic: %s | Partitions: %d | Replication: %d", name, spec.Partitions, spec.ReplicationFactor)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import "strings"
+
+// KafkaConfigBuilder builds a KafkaConfig programmatically, starting from the same
+// defaults as Load, for tests and environments without Vault.
+type KafkaConfigBuilder struct {
+	cfg KafkaConfig
+}
+
+// NewKafkaConfigBuilder creates a KafkaConfigBuilder initialized with
+// DefaultKafkaConfig.
+func NewKafkaConfigBuilder() *KafkaConfigBuilder {
+	return &KafkaConfigBuilder{cfg: DefaultKafkaConfig()}
+}
+
+// WithBrokers sets the broker addresses, e.g. "kafka-1:9092", "kafka-2:9092".
+func (b *KafkaConfigBuilder) WithBrokers(brokers ...string) *KafkaConfigBuilder {
+	b.cfg.Brokers = strings.Join(brokers, ",")
+	return b
+}
+
+// WithSASL enables SASL authentication with the given mechanism (e.g., "PLAIN" or
+// "SCRAM-SHA-256") and credentials.
+func (b *KafkaConfigBuilder) WithSASL(mechanism, username, password string) *KafkaConfigBuilder {
+	b.cfg.SASLEnabled = true
+	b.cfg.SASLMechanism = mechanism
+	b.cfg.SASLUsername = username
+	b.cfg.SASLPassword = password
+	return b
+}
+
+// WithTopics sets the topic of every notification channel.
+func (b *KafkaConfigBuilder) WithTopics(sms, email, inApp, push string) *KafkaConfigBuilder {
+	b.cfg.SMSTopic = sms
+	b.cfg.EmailTopic = email
+	b.cfg.InAppTopic = inApp
+	b.cfg.PushTopic = push
+	return b
+}
+
+// WithTopicPrefix sets the prefix prepended to every topic name.
+func (b *KafkaConfigBuilder) WithTopicPrefix(prefix string) *KafkaConfigBuilder {
+	b.cfg.TopicPrefix = prefix
+	return b
+}
+
+// WithConsumerGroup sets the consumer group ID.
+func (b *KafkaConfigBuilder) WithConsumerGroup(group string) *KafkaConfigBuilder {
+	b.cfg.ConsumerGroup = group
+	return b
+}
+
+// WithClientID sets the client ID reported to the brokers.
+func (b *KafkaConfigBuilder) WithClientID(clientID string) *KafkaConfigBuilder {
+	b.cfg.ClientID = clientID
+	return b
+}
+
+// WithDefaultLocale sets the locale used when a message does not set one.
+func (b *KafkaConfigBuilder) WithDefaultLocale(locale string) *KafkaConfigBuilder {
+	b.cfg.DefaultLocale = locale
+	return b
+}
+
+// WithAutoOffsetReset sets the offset reset policy, "earliest" or "latest".
+func (b *KafkaConfigBuilder) WithAutoOffsetReset(policy string) *KafkaConfigBuilder {
+	b.cfg.AutoOffsetReset = policy
+	return b
+}
+
+// WithTopicOffsetReset sets the offset reset policy of topic, "earliest" or "latest",
+// overriding the policy set by WithAutoOffsetReset for that topic.
+func (b *KafkaConfigBuilder) WithTopicOffsetReset(topic, policy string) *KafkaConfigBuilder {
+	if b.cfg.TopicOffsetReset == nil {
+		b.cfg.TopicOffsetReset = make(map[string]string)
+	}
+	b.cfg.TopicOffsetReset[topic] = policy
+	return b
+}
+
+// WithAutoCreateTopics enables creating missing topics on startup with the given
+// partition count and replication factor.
+func (b *KafkaConfigBuilder) WithAutoCreateTopics(partitions, replication int) *KafkaConfigBuilder {
+	b.cfg.AutoCreateTopics = true
+	b.cfg.TopicPartitions = partitions
+	b.cfg.TopicReplication = replication
+	return b
+}
+
+// Build returns the built KafkaConfig.
+//
+// Returns an error if the config fails Validate.
+func (b *KafkaConfigBuilder) Build() (KafkaConfig, error) {
+	if err := b.cfg.Validate(); err != nil {
+		return KafkaConfig{}, err
+	}
+	return b.cfg, nil
+}
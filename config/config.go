@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 )
@@ -17,30 +19,236 @@ type ConfigParsed struct {
 
 // KafkaConfig holds configuration settings for Kafka integration.
 type KafkaConfig struct {
-	Brokers          string `json:"brokers"`            // Comma-separated list of Kafka broker addresses
-	SMSTopic         string `json:"sms_topic"`         // Topic for SMS notifications
-	EmailTopic       string `json:"email_topic"`       // Topic for email notifications
-	InAppTopic       string `json:"inapp_topic"`       // Topic for in-app notifications
-	PushTopic        string `json:"push_topic"`        // Topic for push notifications
-	ConsumerGroup    string `json:"consumer_group"`    // Kafka consumer group ID
-	SASLEnabled      bool   `json:"sasl_enabled"`      // Whether SASL authentication is enabled
-	SASLUsername     string `json:"sasl_username"`     // SASL username for authentication
-	SASLPassword     string `json:"sasl_password"`     // SASL password for authentication
-	SASLMechanism    string `json:"sasl_mechanism"`    // SASL mechanism (e.g., PLAIN, SCRAM-SHA-256)
-	AutoOffsetReset  string `json:"auto_offset_reset"` // Offset reset policy (e.g., earliest, latest)
+	Brokers          string `json:"brokers"`            // Comma-separated list of Kafka broker addresses, or a DNS SRV name
+	SMSTopic         string `json:"sms_topic"`          // Topic for SMS notifications
+	EmailTopic       string `json:"email_topic"`        // Topic for email notifications
+	InAppTopic       string `json:"inapp_topic"`        // Topic for in-app notifications
+	PushTopic        string `json:"push_topic"`         // Topic for push notifications
+	ConsumerGroup    string `json:"consumer_group"`     // Kafka consumer group ID
+	SASLEnabled      bool   `json:"sasl_enabled"`       // Whether SASL authentication is enabled
+	SASLUsername     string `json:"sasl_username"`      // SASL username for authentication
+	SASLPassword     string `json:"sasl_password"`      // SASL password for authentication
+	SASLMechanism    string `json:"sasl_mechanism"`     // SASL mechanism (e.g., PLAIN, SCRAM-SHA-256)
+	AutoOffsetReset  string `json:"auto_offset_reset"`  // Offset reset policy (e.g., earliest, latest)
 	EnableAutoCommit bool   `json:"enable_auto_commit"` // Whether to enable auto-commit for consumer offsets
 	SessionTimeoutMs int    `json:"session_timeout_ms"` // Consumer group session timeout in milliseconds
+	MaxMessageBytes  int    `json:"max_message_bytes"`  // Maximum size of a published message in bytes
+	TopicPrefix      string `json:"topic_prefix"`       // Prefix prepended to every topic name (e.g., "tenantA.")
+	AutoCreateTopics bool   `json:"auto_create_topics"` // Whether to create missing notification topics on startup
+	TopicPartitions  int    `json:"topic_partitions"`   // Partition count for auto-created topics
+	TopicReplication int    `json:"topic_replication"`  // Replication factor for auto-created topics
+	DefaultLocale    string `json:"default_locale"`     // BCP 47 locale used when a message does not set one
+	ClientID         string `json:"client_id"`          // Client ID reported to the brokers (e.g., "payments-notifier")
+	FetchMin         int    `json:"fetch_min"`          // Minimum bytes per consumer fetch; zero keeps Sarama's default
+	FetchDefault     int    `json:"fetch_default"`      // Bytes requested per partition per consumer fetch; zero keeps Sarama's default
+	FetchMax         int    `json:"fetch_max"`          // Maximum bytes per partition per consumer fetch; zero means no limit
+	MaxWaitTimeMs    int    `json:"max_wait_time_ms"`   // How long the broker waits for FetchMin bytes; zero keeps Sarama's default
+	DialTimeoutMs    int    `json:"dial_timeout_ms"`    // Timeout for connecting to a broker; zero keeps Sarama's default
+	ReadTimeoutMs    int    `json:"read_timeout_ms"`    // Timeout for a broker response; zero keeps Sarama's default
+	WriteTimeoutMs   int    `json:"write_timeout_ms"`   // Timeout for sending a broker request; zero keeps Sarama's default
+	// MetadataRefreshIntervalMs is how often cluster metadata is refreshed in the
+	// background, so publishes follow partition leader changes sooner; zero keeps
+	// Sarama's default of 10 minutes.
+	MetadataRefreshIntervalMs int `json:"metadata_refresh_interval_ms"`
+	// MetadataRetryMax is how many times a failed metadata request is retried, for
+	// example while a partition leader election is in progress; zero keeps Sarama's
+	// default.
+	MetadataRetryMax int `json:"metadata_retry_max"`
+	// ChannelBufferSize is how many messages the producer's internal channels buffer;
+	// zero keeps Sarama's default of 256. Larger buffers absorb publish bursts in
+	// fire-and-forget mode without blocking callers, at the cost of memory and of more
+	// messages lost if the process crashes before they are delivered.
+	ChannelBufferSize int `json:"channel_buffer_size"`
+	// PayloadEncryptionKey is the base64-encoded AES key (16, 24, or 32 bytes) used to
+	// encrypt payloads with producer.WithPayloadEncryption and decrypt them in consumers.
+	PayloadEncryptionKey string `json:"payload_encryption_key"`
+	// TopicOffsetReset overrides AutoOffsetReset for individual topics, keyed by topic
+	// name without TopicPrefix, so that one consumer group can start some
+	// topics at "earliest" and others at "latest".
+	TopicOffsetReset map[string]string `json:"topic_offset_reset"`
+}
+
+// DefaultKafkaConfig returns the KafkaConfig defaults that Load applies to settings
+// absent from Vault and the environment. Brokers has no default.
+func DefaultKafkaConfig() KafkaConfig {
+	return KafkaConfig{
+		SMSTopic:         "sms-notifications",
+		EmailTopic:       "email-notifications",
+		InAppTopic:       "inapp-notifications",
+		PushTopic:        "push-notifications",
+		ConsumerGroup:    "notification-service",
+		SASLMechanism:    "PLAIN",
+		AutoOffsetReset:  "earliest",
+		EnableAutoCommit: true,
+		SessionTimeoutMs: 10000,
+		MaxMessageBytes:  1000000,
+		TopicPartitions:  3,
+		TopicReplication: 3,
+		DefaultLocale:    "en",
+		ClientID:         "notification-producer",
+	}
+}
+
+// SupportedSASLMechanisms lists the SASLMechanism values accepted when SASL is enabled.
+var SupportedSASLMechanisms = []string{"PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512"}
+
+// Validate checks that k has the settings every client needs: brokers, a distinct name
+// for every notification topic, and complete SASL settings if SASL is enabled.
+//
+// Returns an error naming the first missing or invalid setting.
+func (k KafkaConfig) Validate() error {
+	if strings.TrimSpace(k.Brokers) == "" {
+		return fmt.Errorf("Kafka brokers not configured")
+	}
+	if err := k.ValidateSASL(); err != nil {
+		return err
+	}
+	for _, topic := range k.channelTopics() {
+		if topic.value == "" {
+			return fmt.Errorf("Kafka %s not configured", topic.name)
+		}
+	}
+	return k.ValidateTopics()
+}
+
+// ValidateTopics checks that no two notification channels are configured with the
+// same topic, such as EmailTopic and SMSTopic both left at one value, which would
+// interleave the channels' messages on a single topic. Unset topics are ignored.
+//
+// Returns an error naming the channels that share a topic.
+func (k KafkaConfig) ValidateTopics() error {
+	channels := make(map[string]string, 4)
+	for _, topic := range k.channelTopics() {
+		if topic.value == "" {
+			continue
+		}
+		if other, ok := channels[topic.value]; ok {
+			return fmt.Errorf("Kafka %s and %s are both set to topic %q", other, topic.name, topic.value)
+		}
+		channels[topic.value] = topic.name
+	}
+	return nil
+}
+
+// channelTopics returns the configured topic of every notification channel, with the
+// name of its setting.
+func (k KafkaConfig) channelTopics() []struct{ name, value string } {
+	return []struct{ name, value string }{
+		{"SMSTopic", k.SMSTopic},
+		{"EmailTopic", k.EmailTopic},
+		{"InAppTopic", k.InAppTopic},
+		{"PushTopic", k.PushTopic},
+	}
+}
+
+// Topic returns name with TopicPrefix prepended. It is applied once, where a
+// configured or caller-supplied name is published to or consumed from, so names must
+// be given without the prefix.
+func (k KafkaConfig) Topic(name string) string {
+	return k.TopicPrefix + name
+}
+
+// OffsetReset returns the offset reset policy of topic, a name including TopicPrefix:
+// its TopicOffsetReset override, or AutoOffsetReset if it has none.
+func (k KafkaConfig) OffsetReset(topic string) string {
+	for name, policy := range k.TopicOffsetReset {
+		if k.Topic(name) == topic {
+			return policy
+		}
+	}
+	return k.AutoOffsetReset
+}
+
+// redactedValue replaces secret values in redacted configs.
+const redactedValue = "****"
+
+// Redacted returns a copy of c with every secret masked, safe to log.
+func (c ConfigParsed) Redacted() ConfigParsed {
+	c.Kafka = c.Kafka.Redacted()
+	return c
+}
+
+// Redacted returns a copy of k with SASLPassword and PayloadEncryptionKey masked as
+// "****" if they are set, so the effective config can be logged without leaking
+// credentials.
+func (k KafkaConfig) Redacted() KafkaConfig {
+	if k.SASLPassword != "" {
+		k.SASLPassword = redactedValue
+	}
+	if k.PayloadEncryptionKey != "" {
+		k.PayloadEncryptionKey = redactedValue
+	}
+	return k
+}
+
+// ValidateSASL checks that, when SASL is enabled, the username and password are set
+// and the mechanism is one of SupportedSASLMechanisms (case-insensitive).
+//
+// Returns an error naming the missing field or the unsupported mechanism.
+func (k KafkaConfig) ValidateSASL() error {
+	if !k.SASLEnabled {
+		return nil
+	}
+	if k.SASLUsername == "" {
+		return fmt.Errorf("SASL is enabled but SASLUsername (KAFKA_SASL_USERNAME) is empty")
+	}
+	if k.SASLPassword == "" {
+		return fmt.Errorf("SASL is enabled but SASLPassword (KAFKA_SASL_PASSWORD) is empty")
+	}
+	for _, mechanism := range SupportedSASLMechanisms {
+		if strings.EqualFold(k.SASLMechanism, mechanism) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported SASLMechanism %q, must be one of %s", k.SASLMechanism, strings.Join(SupportedSASLMechanisms, ", "))
+}
+
+// SecretStore is a source of configuration secrets keyed by name. VaultClient is the
+// default implementation and FileSecretStore reads mounted secret files; tests and
+// callers with their own secret source can supply another one to Load via
+// WithSecretStore.
+type SecretStore interface {
+	// GetSecret returns the value stored under key, or an empty string if there is none.
+	GetSecret(key string) (string, error)
+}
+
+// NopSecretStore is a SecretStore that holds no secrets. Combined with PrecedenceEnv it
+// lets Load run without Vault, using only environment variables and defaults.
+type NopSecretStore struct{}
+
+// GetSecret always returns an empty string.
+func (NopSecretStore) GetSecret(string) (string, error) {
+	return "", nil
 }
 
 // VaultClient wraps the HashiCorp Vault client with caching capabilities for secrets.
 type VaultClient struct {
-	client     *api.Client
-	path       string
-	secretData map[string]interface{}
+	client       *api.Client
+	paths        []string
+	secretData   map[string]interface{}
+	maxAttempts  int           // Read attempts per path before giving up
+	retryTimeout time.Duration // Upper bound on the time spent retrying a path
 }
 
+// Defaults for retrying Vault reads, overridable with VAULT_RETRY_ATTEMPTS and
+// VAULT_RETRY_TIMEOUT_MS.
+const (
+	defaultVaultRetryAttempts = 5
+	defaultVaultRetryTimeout  = 30 * time.Second
+	vaultRetryInitialBackoff  = 500 * time.Millisecond
+	vaultRetryMaxBackoff      = 5 * time.Second
+)
+
 // NewVaultClient creates a new Vault client using environment variables for configuration.
-// It initializes the client with the Vault address and token, and fetches secrets from the specified path.
+// It initializes the client with the Vault address and token, and fetches secrets from the
+// paths in VAULT_PATH. VAULT_PATH may list several comma-separated paths (e.g.,
+// "secret/data/kafka,secret/data/mailjet"), which are merged in order, with later paths
+// overriding earlier ones on key conflicts.
+//
+// Reads that fail, for example because Vault is sealed or briefly unreachable during
+// a rollout, are retried with exponential backoff up to VAULT_RETRY_ATTEMPTS times
+// (default 5) and for at most VAULT_RETRY_TIMEOUT_MS milliseconds (default 30000).
+// Missing or malformed secrets are not retried.
 //
 // Returns a new VaultClient or an error if initialization or secret fetching fails.
 func NewVaultClient() (*VaultClient, error) {
@@ -55,9 +263,27 @@ func NewVaultClient() (*VaultClient, error) {
 
 	client.SetToken(getEnv("VAULT_TOKEN"))
 
+	var paths []string
+	for _, path := range strings.Split(getEnv("VAULT_PATH"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
 	vault := &VaultClient{
-		client: client,
-		path:   getEnv("VAULT_PATH"),
+		client:       client,
+		paths:        paths,
+		maxAttempts:  defaultVaultRetryAttempts,
+		retryTimeout: defaultVaultRetryTimeout,
+	}
+	if attempts, err := strconv.Atoi(getEnv("VAULT_RETRY_ATTEMPTS")); err == nil && attempts > 0 {
+		vault.maxAttempts = attempts
+	}
+	if timeoutMs, err := strconv.Atoi(getEnv("VAULT_RETRY_TIMEOUT_MS")); err == nil && timeoutMs > 0 {
+		vault.retryTimeout = time.Duration(timeoutMs) * time.Millisecond
 	}
 
 	if err := vault.fetchSecrets(); err != nil {
@@ -68,25 +294,66 @@ func NewVaultClient() (*VaultClient, error) {
 }
 
 // fetchSecrets retrieves secrets from Vault and caches them in the VaultClient.
-// It reads secrets from the configured path and stores them in secretData.
+// It reads secrets from each configured path in order and merges them into secretData,
+// with later paths overriding earlier ones.
 //
-// Returns an error if the read operation fails or no secrets are found.
+// Returns an error if any read operation fails or a path holds no secrets.
 func (v *VaultClient) fetchSecrets() error {
-	secret, err := v.client.Logical().Read(v.path)
+	secretData := make(map[string]interface{})
+	for _, path := range v.paths {
+		data, err := v.readPath(path)
+		if err != nil {
+			return err
+		}
+		for key, value := range data {
+			secretData[key] = value
+		}
+	}
+
+	v.secretData = secretData
+	return nil
+}
+
+// readPath reads the secrets stored at a single Vault path.
+//
+// Returns an error if the read operation fails or no secrets are found.
+func (v *VaultClient) readPath(path string) (map[string]interface{}, error) {
+	secret, err := v.readWithRetry(path)
 	if err != nil {
-		return fmt.Errorf("failed to read secrets from Vault path %s: %w", v.path, err)
+		return nil, fmt.Errorf("failed to read secrets from Vault path %s: %w", path, err)
 	}
 
 	if secret == nil || secret.Data == nil {
-		return fmt.Errorf("no secrets found at path: %s", v.path)
+		return nil, fmt.Errorf("no secrets found at path: %s", path)
 	}
 
 	if data, ok := secret.Data["data"].(map[string]interface{}); ok {
-		v.secretData = data
-		return nil
+		return data, nil
 	}
 
-	return fmt.Errorf("invalid secret data format at path: %s", v.path)
+	return nil, fmt.Errorf("invalid secret data format at path: %s", path)
+}
+
+// readWithRetry reads path, retrying failed reads with exponential backoff until
+// maxAttempts or retryTimeout is exhausted.
+//
+// Returns the last read error, annotated with the number of attempts made.
+func (v *VaultClient) readWithRetry(path string) (*api.Secret, error) {
+	deadline := time.Now().Add(v.retryTimeout)
+	backoff := vaultRetryInitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		secret, err := v.client.Logical().Read(path)
+		if err == nil {
+			return secret, nil
+		}
+		if attempt >= v.maxAttempts || time.Now().Add(backoff).After(deadline) {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		time.Sleep(backoff)
+		backoff = min(backoff*2, vaultRetryMaxBackoff)
+	}
 }
 
 // GetSecret retrieves a secret value from the cached Vault secrets by key.
@@ -99,28 +366,99 @@ func (v *VaultClient) GetSecret(key string) (string, error) {
 	return "", nil
 }
 
-// Load loads Kafka configuration from environment variables with Vault fallback.
-// It constructs a ConfigParsed instance with Kafka settings, using defaults if necessary.
+// Precedence selects which source wins when a setting is present in both Vault and
+// the environment.
+type Precedence int
+
+const (
+	// PrecedenceVault uses Vault (or the configured SecretStore) values and ignores the
+	// environment. This is the default.
+	PrecedenceVault Precedence = iota
+	// PrecedenceEnv uses environment variables that are set and non-empty, falling back
+	// to Vault for the rest. Use it to override a Vault value locally, e.g. to point at a
+	// different broker while reproducing a production issue.
+	PrecedenceEnv
+)
+
+// LoadOption customizes how Load resolves configuration values.
+type LoadOption func(*loadOptions)
+
+// loadOptions holds the settings applied by LoadOption values.
+type loadOptions struct {
+	precedence Precedence
+	store      SecretStore
+}
+
+// WithPrecedence sets the precedence between Vault and environment variables. When no
+// option is given, Load uses PrecedenceEnv if CONFIG_PRECEDENCE is "env" and
+// PrecedenceVault otherwise.
+func WithPrecedence(p Precedence) LoadOption {
+	return func(o *loadOptions) {
+		o.precedence = p
+	}
+}
+
+// WithSecretStore makes Load read secrets from store instead of creating one from
+// CONFIG_BACKEND and the environment.
+func WithSecretStore(store SecretStore) LoadOption {
+	return func(o *loadOptions) {
+		o.store = store
+	}
+}
+
+// Load loads Kafka configuration from Vault, using defaults if necessary. By default
+// Vault values take precedence and the environment is not consulted; see
+// WithPrecedence to let environment variables override Vault. Each setting is read
+// from the environment variable with the same name as its Vault key (e.g., KAFKA_BROKERS).
 //
-// Returns the parsed configuration or an error if Vault initialization fails.
-func Load() (*ConfigParsed, error) {
-	vaultClient, err := NewVaultClient()
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Vault client: %w", err)
+// When CONFIG_BACKEND is "file", secrets are read from a FileSecretStore on the
+// directory in CONFIG_SECRETS_DIR, such as a mounted Kubernetes Secret, instead of
+// Vault.
+//
+// Returns the parsed configuration or an error if no SecretStore is supplied and the
+// secret store selected by CONFIG_BACKEND fails to initialize.
+func Load(opts ...LoadOption) (*ConfigParsed, error) {
+	o := loadOptions{precedence: PrecedenceVault}
+	if strings.EqualFold(getEnv("CONFIG_PRECEDENCE"), "env") {
+		o.precedence = PrecedenceEnv
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	store := o.store
+	if store == nil {
+		var err error
+		if store, err = newSecretStore(); err != nil {
+			return nil, err
+		}
 	}
 
-	// Helper function to get config values with Vault and default fallbacks
-	getConfigValue := func(vaultKey, defaultValue string) string {
-		if vaultValue, err := vaultClient.GetSecret(vaultKey); err == nil && vaultValue != "" {
-			return vaultValue
+	// Helper function to look up a raw value by key according to the precedence
+	lookup := func(key string) string {
+		if o.precedence == PrecedenceEnv {
+			if envValue := getEnv(key); envValue != "" {
+				return envValue
+			}
+		}
+		if secretValue, err := store.GetSecret(key); err == nil {
+			return secretValue
+		}
+		return ""
+	}
+
+	// Helper function to get config values with default fallback
+	getConfigValue := func(key, defaultValue string) string {
+		if value := lookup(key); value != "" {
+			return value
 		}
 		return defaultValue
 	}
 
 	// Helper for boolean values
-	getConfigBool := func(vaultKey string, defaultValue bool) bool {
-		if vaultValue, err := vaultClient.GetSecret(vaultKey); err == nil && vaultValue != "" {
-			if boolValue, err := strconv.ParseBool(vaultValue); err == nil {
+	getConfigBool := func(key string, defaultValue bool) bool {
+		if value := lookup(key); value != "" {
+			if boolValue, err := strconv.ParseBool(value); err == nil {
 				return boolValue
 			}
 		}
@@ -128,41 +466,106 @@ func Load() (*ConfigParsed, error) {
 	}
 
 	// Helper for integer values
-	getConfigInt := func(vaultKey string, defaultValue int) int {
-		if vaultValue, err := vaultClient.GetSecret(vaultKey); err == nil && vaultValue != "" {
-			if intValue, err := strconv.Atoi(vaultValue); err == nil {
+	getConfigInt := func(key string, defaultValue int) int {
+		if value := lookup(key); value != "" {
+			if intValue, err := strconv.Atoi(value); err == nil {
 				return intValue
 			}
 		}
 		return defaultValue
 	}
 
+	// Helper for maps of "key=value" pairs separated by commas
+	getConfigMap := func(key string) map[string]string {
+		value := lookup(key)
+		if value == "" {
+			return nil
+		}
+		m := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(pair, "=")
+			if ok && strings.TrimSpace(k) != "" {
+				m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+		return m
+	}
+
 	// Build Kafka configuration
+	defaults := DefaultKafkaConfig()
 	cfg := &ConfigParsed{
 		Kafka: KafkaConfig{
-			Brokers:          getConfigValue("KAFKA_BROKERS", ""),
-			SMSTopic:         getConfigValue("KAFKA_SMS_TOPIC", "sms-notifications"),
-			EmailTopic:       getConfigValue("KAFKA_EMAIL_TOPIC", "email-notifications"),
-			InAppTopic:       getConfigValue("KAFKA_INAPP_TOPIC", "inapp-notifications"),
-			PushTopic:        getConfigValue("KAFKA_PUSH_TOPIC", "push-notifications"),
-			ConsumerGroup:    getConfigValue("KAFKA_CONSUMER_GROUP", "notification-service"),
-			SASLEnabled:      getConfigBool("KAFKA_SASL_ENABLED", false),
-			SASLUsername:     getConfigValue("KAFKA_SASL_USERNAME", ""),
-			SASLPassword:     getConfigValue("KAFKA_SASL_PASSWORD", ""),
-			SASLMechanism:    getConfigValue("KAFKA_SASL_MECHANISM", "PLAIN"),
-			AutoOffsetReset:  getConfigValue("KAFKA_AUTO_OFFSET_RESET", "earliest"),
-			EnableAutoCommit: getConfigBool("KAFKA_ENABLE_AUTO_COMMIT", true),
-			SessionTimeoutMs: getConfigInt("KAFKA_SESSION_TIMEOUT_MS", 10000),
+			Brokers:          getConfigValue("KAFKA_BROKERS", defaults.Brokers),
+			SMSTopic:         getConfigValue("KAFKA_SMS_TOPIC", defaults.SMSTopic),
+			EmailTopic:       getConfigValue("KAFKA_EMAIL_TOPIC", defaults.EmailTopic),
+			InAppTopic:       getConfigValue("KAFKA_INAPP_TOPIC", defaults.InAppTopic),
+			PushTopic:        getConfigValue("KAFKA_PUSH_TOPIC", defaults.PushTopic),
+			ConsumerGroup:    getConfigValue("KAFKA_CONSUMER_GROUP", defaults.ConsumerGroup),
+			SASLEnabled:      getConfigBool("KAFKA_SASL_ENABLED", defaults.SASLEnabled),
+			SASLUsername:     getConfigValue("KAFKA_SASL_USERNAME", defaults.SASLUsername),
+			SASLPassword:     getConfigValue("KAFKA_SASL_PASSWORD", defaults.SASLPassword),
+			SASLMechanism:    getConfigValue("KAFKA_SASL_MECHANISM", defaults.SASLMechanism),
+			AutoOffsetReset:  getConfigValue("KAFKA_AUTO_OFFSET_RESET", defaults.AutoOffsetReset),
+			EnableAutoCommit: getConfigBool("KAFKA_ENABLE_AUTO_COMMIT", defaults.EnableAutoCommit),
+			SessionTimeoutMs: getConfigInt("KAFKA_SESSION_TIMEOUT_MS", defaults.SessionTimeoutMs),
+			MaxMessageBytes:  getConfigInt("KAFKA_MAX_MESSAGE_BYTES", defaults.MaxMessageBytes),
+			TopicPrefix:      getConfigValue("KAFKA_TOPIC_PREFIX", defaults.TopicPrefix),
+			AutoCreateTopics: getConfigBool("KAFKA_AUTO_CREATE_TOPICS", defaults.AutoCreateTopics),
+			TopicPartitions:  getConfigInt("KAFKA_TOPIC_PARTITIONS", defaults.TopicPartitions),
+			TopicReplication: getConfigInt("KAFKA_TOPIC_REPLICATION", defaults.TopicReplication),
+			DefaultLocale:    getConfigValue("KAFKA_DEFAULT_LOCALE", defaults.DefaultLocale),
+			ClientID:         getConfigValue("KAFKA_CLIENT_ID", defaults.ClientID),
+			FetchMin:         getConfigInt("KAFKA_FETCH_MIN", defaults.FetchMin),
+			FetchDefault:     getConfigInt("KAFKA_FETCH_DEFAULT", defaults.FetchDefault),
+			FetchMax:         getConfigInt("KAFKA_FETCH_MAX", defaults.FetchMax),
+			MaxWaitTimeMs:    getConfigInt("KAFKA_MAX_WAIT_TIME_MS", defaults.MaxWaitTimeMs),
+			DialTimeoutMs:    getConfigInt("KAFKA_DIAL_TIMEOUT_MS", defaults.DialTimeoutMs),
+			ReadTimeoutMs:    getConfigInt("KAFKA_READ_TIMEOUT_MS", defaults.ReadTimeoutMs),
+			WriteTimeoutMs:   getConfigInt("KAFKA_WRITE_TIMEOUT_MS", defaults.WriteTimeoutMs),
+
+			MetadataRefreshIntervalMs: getConfigInt("KAFKA_METADATA_REFRESH_INTERVAL_MS", defaults.MetadataRefreshIntervalMs),
+			MetadataRetryMax:          getConfigInt("KAFKA_METADATA_RETRY_MAX", defaults.MetadataRetryMax),
+			ChannelBufferSize:         getConfigInt("KAFKA_CHANNEL_BUFFER_SIZE", defaults.ChannelBufferSize),
+
+			PayloadEncryptionKey: getConfigValue("KAFKA_PAYLOAD_ENCRYPTION_KEY", defaults.PayloadEncryptionKey),
+			TopicOffsetReset:     getConfigMap("KAFKA_TOPIC_OFFSET_RESET"),
 		},
 	}
 
 	return cfg, nil
 }
 
+// newSecretStore creates the SecretStore selected by CONFIG_BACKEND: a VaultClient for
+// "vault" or when unset, and a FileSecretStore on CONFIG_SECRETS_DIR for "file".
+//
+// Returns an error if the backend is unknown or fails to initialize.
+func newSecretStore() (SecretStore, error) {
+	switch backend := strings.ToLower(getEnv("CONFIG_BACKEND")); backend {
+	case "", "vault":
+		vaultClient, err := NewVaultClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Vault client: %w", err)
+		}
+		return vaultClient, nil
+	case "file":
+		dir := getEnv("CONFIG_SECRETS_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("CONFIG_BACKEND is file but CONFIG_SECRETS_DIR is not set")
+		}
+		fileStore, err := NewFileSecretStore(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize file secret store: %w", err)
+		}
+		return fileStore, nil
+	default:
+		return nil, fmt.Errorf("unsupported CONFIG_BACKEND %q, must be vault or file", backend)
+	}
+}
+
 // getEnv retrieves an environment variable by key, returning an empty string if not set.
 func getEnv(key string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return ""
-}
\ No newline at end of file
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSecretStore is a SecretStore that reads each secret from a file named after its
+// key in a directory, which is how Kubernetes projects a mounted Secret volume: the
+// value of KAFKA_BROKERS is the content of <dir>/KAFKA_BROKERS. Files are read on
+// every lookup, so rotated secrets are picked up by the next Load.
+type FileSecretStore struct {
+	dir string
+}
+
+// NewFileSecretStore creates a FileSecretStore reading secrets from dir.
+//
+// Returns an error if dir does not exist or is not a directory.
+func NewFileSecretStore(dir string) (*FileSecretStore, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secrets directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("secrets path %s is not a directory", dir)
+	}
+	return &FileSecretStore{dir: dir}, nil
+}
+
+// GetSecret returns the content of the file named key, without trailing newlines, or
+// an empty string if there is no such file.
+//
+// Returns an error if key is not a plain file name or the file cannot be read.
+func (f *FileSecretStore) GetSecret(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid secret key %q", key)
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
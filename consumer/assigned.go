@@ -0,0 +1,262 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/config"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+)
+
+// TopicPartition identifies a single partition of a topic.
+type TopicPartition struct {
+	Topic     string // Topic name
+	Partition int32  // Partition number
+}
+
+// assignment holds the Sarama clients of a consumer created with NewAssignedConsumer.
+type assignment struct {
+	partitions []TopicPartition
+	client     sarama.Client
+	consumer   sarama.Consumer
+	offsets    sarama.OffsetManager
+	stop       chan struct{}  // Closed by Close to stop a running Start
+	running    sync.WaitGroup // Tracks a running Start, which Close waits for
+}
+
+// NewAssignedConsumer creates a NotificationConsumer that consumes exactly the given
+// partitions, without joining a consumer group, so there are no rebalances and a
+// single instance processes every partition deterministically. Use it for singleton
+// workers and tools. The configured TopicPrefix is prepended to each topic. A nil
+// logger is replaced with a no-op logger. Options customize consumer-wide behavior as
+// for NewNotificationConsumer.
+//
+// Offsets are still committed to Kafka under the configured ConsumerGroup, so a
+// restarted consumer resumes where it left off, following the topic's offset reset
+// policy when no offset has been committed. The group must not also be used by group consumers of
+// the same topics. Start, Close, Ready, and handler registration work as for a group
+// consumer; ProcessTransactionally is not supported.
+//
+// Returns an error if no partitions are given, the consumer group or brokers are not
+// configured, or the Kafka client fails to initialize.
+func NewAssignedConsumer(cfg config.KafkaConfig, partitions []TopicPartition, logger utils.Logger, opts ...Option) (*NotificationConsumer, error) {
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("at least one partition is required")
+	}
+	if cfg.ConsumerGroup == "" {
+		return nil, fmt.Errorf("Kafka consumer group not configured")
+	}
+
+	brokers, err := kafkautil.Brokers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := sarama.NewClient(brokers, newConsumerConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+
+	offsets, err := sarama.NewOffsetManagerFromClient(cfg.ConsumerGroup, client)
+	if err != nil {
+		_ = consumer.Close()
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka offset manager: %w", err)
+	}
+
+	assigned := make([]TopicPartition, len(partitions))
+	var topics []string
+	seen := make(map[string]bool)
+	for i, tp := range partitions {
+		tp.Topic = cfg.Topic(tp.Topic)
+		assigned[i] = tp
+		if !seen[tp.Topic] {
+			seen[tp.Topic] = true
+			topics = append(topics, tp.Topic)
+		}
+	}
+
+	nc, err := newConsumer(cfg, topics, logger, opts)
+	if err != nil {
+		_ = offsets.Close()
+		_ = consumer.Close()
+		_ = client.Close()
+		return nil, err
+	}
+	nc.assigned = &assignment{
+		partitions: assigned,
+		client:     client,
+		consumer:   consumer,
+		offsets:    offsets,
+		stop:       make(chan struct{}),
+	}
+
+	return nc, nil
+}
+
+// partitionSession tracks the offsets of a manually assigned partition, standing in
+// for the consumer group session of a claimed partition.
+type partitionSession struct {
+	ctx     context.Context
+	pom     sarama.PartitionOffsetManager
+	offsets sarama.OffsetManager
+}
+
+// Context returns the context that stops consumption of the partition.
+func (s *partitionSession) Context() context.Context {
+	return s.ctx
+}
+
+// MarkMessage marks msg as consumed, to be committed with the next Commit.
+func (s *partitionSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.pom.MarkOffset(msg.Offset+1, metadata)
+}
+
+// Commit synchronously commits the marked offsets.
+func (s *partitionSession) Commit() {
+	s.offsets.Commit()
+}
+
+// consumeAssigned consumes every assigned partition concurrently, each in order,
+// until ctx is cancelled or the consumer is closed, then commits the marked offsets.
+//
+// Returns nil when ctx is cancelled or the consumer is closed, or an error if a
+// partition cannot be opened, in which case nothing is consumed.
+func (nc *NotificationConsumer) consumeAssigned(ctx context.Context) error {
+	nc.mu.Lock()
+	if nc.closed {
+		nc.mu.Unlock()
+		return nil
+	}
+	nc.assigned.running.Add(1)
+	nc.mu.Unlock()
+	defer nc.assigned.running.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-nc.assigned.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	sessions := make([]*partitionSession, 0, len(nc.assigned.partitions))
+	consumers := make([]sarama.PartitionConsumer, 0, len(nc.assigned.partitions))
+	defer func() {
+		nc.assigned.offsets.Commit()
+		for i, pc := range consumers {
+			if err := pc.Close(); err != nil {
+				nc.logger.Errorf("Error closing partition consumer: %v", err)
+			}
+			sessions[i].pom.AsyncClose()
+		}
+	}()
+
+	for _, tp := range nc.assigned.partitions {
+		pom, pc, err := nc.openPartition(tp)
+		if err != nil {
+			return err
+		}
+		sessions = append(sessions, &partitionSession{ctx: ctx, pom: pom, offsets: nc.assigned.offsets})
+		consumers = append(consumers, pc)
+	}
+	nc.readyOnce.Do(func() { close(nc.ready) })
+	nc.logger.Infof("Kafka consumer ready | Group: %s | Partitions: %d", nc.config.ConsumerGroup, len(consumers))
+
+	var wg sync.WaitGroup
+	for i, pc := range consumers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			nc.consumeMessages(sessions[i], pc.Messages())
+		}()
+		go func() {
+			defer wg.Done()
+			nc.logPartitionErrors(ctx, pc)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// openPartition starts consuming tp from its committed offset, or from the offset
+// selected by the topic's offset reset policy if none has been committed or it is out of range.
+//
+// Returns the partition's offset manager and consumer, or an error if either fails
+// to open.
+func (nc *NotificationConsumer) openPartition(tp TopicPartition) (sarama.PartitionOffsetManager, sarama.PartitionConsumer, error) {
+	pom, err := nc.assigned.offsets.ManagePartition(tp.Topic, tp.Partition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to manage offsets of %s/%d: %w", tp.Topic, tp.Partition, err)
+	}
+
+	initial := initialOffset(nc.config.OffsetReset(tp.Topic))
+	offset, _ := pom.NextOffset()
+	if offset < 0 {
+		offset = initial
+	}
+	pc, err := nc.assigned.consumer.ConsumePartition(tp.Topic, tp.Partition, offset)
+	if errors.Is(err, sarama.ErrOffsetOutOfRange) {
+		nc.logger.Errorf("Committed offset out of range, resetting | Topic: %s | Partition: %d | Offset: %d", tp.Topic, tp.Partition, offset)
+		pc, err = nc.assigned.consumer.ConsumePartition(tp.Topic, tp.Partition, initial)
+	}
+	if err != nil {
+		pom.AsyncClose()
+		return nil, nil, fmt.Errorf("failed to consume %s/%d from offset %d: %w", tp.Topic, tp.Partition, offset, err)
+	}
+	return pom, pc, nil
+}
+
+// logPartitionErrors logs the errors reported by pc until ctx is done or pc is closed.
+func (nc *NotificationConsumer) logPartitionErrors(ctx context.Context, pc sarama.PartitionConsumer) {
+	for {
+		select {
+		case err, ok := <-pc.Errors():
+			if !ok {
+				return
+			}
+			nc.logger.Errorf("Kafka partition consumer error: %v", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// closeAssigned stops a running Start, waits for it to commit its offsets, and closes
+// the Sarama clients of an assigned consumer. It must be called with nc.mu held.
+//
+// Returns the errors from closing the offset manager, consumer, and client.
+func (nc *NotificationConsumer) closeAssigned() []error {
+	close(nc.assigned.stop)
+	nc.assigned.running.Wait()
+
+	var errs []error
+	if err := nc.assigned.offsets.Close(); err != nil {
+		nc.logger.Errorf("Error closing Kafka offset manager: %v", err)
+		errs = append(errs, fmt.Errorf("failed to close Kafka offset manager: %w", err))
+	}
+	if err := nc.assigned.consumer.Close(); err != nil {
+		nc.logger.Errorf("Error closing Kafka consumer: %v", err)
+		errs = append(errs, fmt.Errorf("failed to close Kafka consumer: %w", err))
+	}
+	if err := nc.assigned.client.Close(); err != nil {
+		nc.logger.Errorf("Error closing Kafka client: %v", err)
+		errs = append(errs, fmt.Errorf("failed to close Kafka client: %w", err))
+	}
+	if len(errs) == 0 {
+		nc.logger.Infof("Kafka consumer closed successfully")
+	}
+	return errs
+}
@@ -0,0 +1,75 @@
+package consumer
+
+import "github.com/IBM/sarama"
+
+// compactionBatchSize caps the number of buffered messages collected into a single
+// batch in compacted mode.
+const compactionBatchSize = 500
+
+// consumeCompacted processes first together with the messages already buffered on
+// messages as one batch, skipping every keyed message that a later message in the
+// batch with the same key supersedes. The batch's last offset is marked once it has
+// been handled, which also covers the skipped messages.
+func (nc *NotificationConsumer) consumeCompacted(session claimSession, messages <-chan *sarama.ConsumerMessage, first *sarama.ConsumerMessage) {
+	batch := nextBatch(first, messages)
+	last := batch[len(batch)-1]
+	latest := latestPerKey(batch)
+	if skipped := len(batch) - len(latest); skipped > 0 {
+		nc.logger.Infof("Skipping superseded messages | Topic: %s | Partition: %d | Count: %d", last.Topic, last.Partition, skipped)
+	}
+
+	if nc.semantics == AtMostOnce {
+		session.MarkMessage(last, "")
+		session.Commit()
+		for _, msg := range latest {
+			nc.process(session.Context(), msg)
+		}
+		return
+	}
+
+	for _, msg := range latest {
+		nc.process(session.Context(), msg)
+		session.MarkMessage(msg, "")
+	}
+	session.MarkMessage(last, "")
+	if !nc.config.EnableAutoCommit {
+		session.Commit()
+	}
+}
+
+// nextBatch returns first followed by the messages that are already buffered on
+// messages, without waiting for more, up to compactionBatchSize in total.
+func nextBatch(first *sarama.ConsumerMessage, messages <-chan *sarama.ConsumerMessage) []*sarama.ConsumerMessage {
+	batch := []*sarama.ConsumerMessage{first}
+	for len(batch) < compactionBatchSize {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, msg)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// latestPerKey returns the messages of batch that are not superseded by a later
+// message with the same key, in offset order. Keyless messages are always kept.
+func latestPerKey(batch []*sarama.ConsumerMessage) []*sarama.ConsumerMessage {
+	lastIndex := make(map[string]int, len(batch))
+	for i, msg := range batch {
+		if len(msg.Key) > 0 {
+			lastIndex[string(msg.Key)] = i
+		}
+	}
+
+	latest := make([]*sarama.ConsumerMessage, 0, len(lastIndex))
+	for i, msg := range batch {
+		if len(msg.Key) == 0 || lastIndex[string(msg.Key)] == i {
+			latest = append(latest, msg)
+		}
+	}
+	return latest
+}
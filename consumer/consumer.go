@@ -0,0 +1,565 @@
+package consumer
+
+import (
+	"context"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/config"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+	"github.com/dawit-go/notification-kafka-lib/metrics"
+	"github.com/dawit-go/notification-kafka-lib/producer"
+	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler processes a single notification message. The source topic, partition, and
+// offset are available from the context via MessageInfoFromContext.
+type Handler func(ctx context.Context, msg *dto.NotificationMessage) error
+
+// NotificationConsumer consumes notification messages from one or more topics in a
+// single consumer group session and dispatches each message to the handler registered
+// for its Type, falling back to the handler registered for its source topic.
+//
+// By default offsets are marked after the handler returns, whether or not it
+// succeeded; handler errors are logged. See WithDeliverySemantics. Payloads encrypted
+// by the producer are decrypted with the configured PayloadEncryptionKey.
+type NotificationConsumer struct {
+	group         sarama.ConsumerGroup
+	client        sarama.Client // Client of the consumer group; nil for assigned consumers
+	topics        []string
+	logger        producer.Logger
+	config        config.KafkaConfig
+	handlers      map[string]Handler // Handlers keyed by message type
+	topicHandlers map[string]Handler // Fallback handlers keyed by source topic
+	middlewares   []Middleware       // Applied around every handler at dispatch, first is outermost
+	handlerMu     sync.RWMutex
+	seen          SeenStore     // Optional store of processed message IDs and idempotency keys
+	seenTTL       time.Duration // How long processed message IDs and keys are remembered
+	semantics     DeliverySemantics
+	compacted     bool                    // Whether only the latest message per key in a batch is handled
+	router        RouteFunc               // Optional handler selection replacing routing by Type
+	sharedTopics  map[string]bool         // Topics configured for several channels, never routed by topic
+	metrics       metrics.HandlerRecorder // Optional recorder of handler durations
+	tracer        trace.Tracer            // Optional tracer of handler invocations
+	payloadCipher cipher.AEAD             // Decrypts encrypted payloads; nil without PayloadEncryptionKey
+	dlqTopic      string                  // Optional dead-letter topic for failed messages
+	dlqExpired    bool                    // Whether expired messages are dead-lettered rather than dropped
+	maxAge        time.Duration           // Messages older than this are skipped; zero disables the check
+	failures      FailureTracker          // Optional tracker of failed attempts per message ID
+	maxFailures   int                     // Failed attempts after which a message is treated as poison
+	dlq           sarama.SyncProducer     // Producer for the dead-letter topic, if configured
+	assigned      *assignment             // Manually assigned partitions; nil for group consumers
+	ready         chan struct{}           // Closed once the first session has been assigned partitions
+	readyOnce     sync.Once
+	mu            sync.Mutex
+	closed        bool
+}
+
+// NewNotificationConsumer creates a new NotificationConsumer that joins the configured
+// consumer group and subscribes to topics, e.g. []string{cfg.EmailTopic, cfg.SMSTopic}.
+// The configured TopicPrefix is prepended to each topic. A nil logger is replaced with
+// a no-op logger. Options customize consumer-wide behavior.
+//
+// Returns an error if no topics are given, the brokers list is empty, or the consumer
+// group fails to initialize.
+func NewNotificationConsumer(cfg config.KafkaConfig, topics []string, logger utils.Logger, opts ...Option) (*NotificationConsumer, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("at least one topic is required")
+	}
+	if cfg.ConsumerGroup == "" {
+		return nil, fmt.Errorf("Kafka consumer group not configured")
+	}
+
+	brokers, err := kafkautil.Brokers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := sarama.NewClient(brokers, newConsumerConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	group, err := sarama.NewConsumerGroupFromClient(cfg.ConsumerGroup, client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer group: %w", err)
+	}
+
+	subscribed := make([]string, len(topics))
+	for i, topic := range topics {
+		subscribed[i] = cfg.Topic(topic)
+	}
+
+	nc, err := newConsumer(cfg, subscribed, logger, opts)
+	if err != nil {
+		_ = group.Close()
+		_ = client.Close()
+		return nil, err
+	}
+	nc.group = group
+	nc.client = client
+	go nc.logErrors()
+
+	return nc, nil
+}
+
+// newConsumer creates a NotificationConsumer for topics with opts applied, and its
+// dead-letter producer if one is configured. The caller sets up the Kafka consumer.
+//
+// Returns an error if the PayloadEncryptionKey is invalid or the dead-letter producer
+// fails to initialize.
+func newConsumer(cfg config.KafkaConfig, topics []string, logger utils.Logger, opts []Option) (*NotificationConsumer, error) {
+	payloadCipher, err := kafkautil.NewPayloadCipher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nc := &NotificationConsumer{
+		payloadCipher: payloadCipher,
+		topics:        topics,
+		logger:        loggerOrNop(logger),
+		config:        cfg,
+		handlers:      make(map[string]Handler),
+		topicHandlers: make(map[string]Handler),
+		ready:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(nc)
+	}
+	if err := cfg.ValidateTopics(); err != nil {
+		nc.logger.Errorf("Kafka topic misconfiguration, messages are routed by type rather than topic: %v", err)
+		nc.sharedTopics = sharedTopics(cfg)
+	}
+
+	if nc.dlqTopic != "" {
+		if nc.dlq, err = nc.newDeadLetterProducer(); err != nil {
+			return nil, err
+		}
+	}
+	return nc, nil
+}
+
+// newConsumerConfig builds the Sarama consumer group configuration for cfg. Messages
+// from aborted transactions are never delivered.
+func newConsumerConfig(cfg config.KafkaConfig) *sarama.Config {
+	kafkaConfig := kafkautil.NewSaramaConfig(cfg)
+	kafkaConfig.Consumer.Return.Errors = true
+	kafkaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+	kafkaConfig.Consumer.Offsets.AutoCommit.Enable = cfg.EnableAutoCommit
+	kafkaConfig.Consumer.Offsets.Initial = initialOffset(cfg.AutoOffsetReset)
+	if cfg.SessionTimeoutMs > 0 {
+		kafkaConfig.Consumer.Group.Session.Timeout = time.Duration(cfg.SessionTimeoutMs) * time.Millisecond
+	}
+	applyFetchConfig(kafkaConfig, cfg)
+	return kafkaConfig
+}
+
+// applyFetchConfig applies the fetch sizing from cfg to kafkaConfig, keeping Sarama's
+// defaults for the settings that are not set.
+func applyFetchConfig(kafkaConfig *sarama.Config, cfg config.KafkaConfig) {
+	if cfg.FetchMin > 0 {
+		kafkaConfig.Consumer.Fetch.Min = int32(cfg.FetchMin)
+	}
+	if cfg.FetchDefault > 0 {
+		kafkaConfig.Consumer.Fetch.Default = int32(cfg.FetchDefault)
+	}
+	if cfg.FetchMax > 0 {
+		kafkaConfig.Consumer.Fetch.Max = int32(cfg.FetchMax)
+	}
+	if cfg.MaxWaitTimeMs > 0 {
+		kafkaConfig.Consumer.MaxWaitTime = time.Duration(cfg.MaxWaitTimeMs) * time.Millisecond
+	}
+}
+
+// Ready reports whether the consumer has joined its group and been assigned
+// partitions in at least one session. Use it as a readiness check, so that traffic is
+// not routed to an instance that is not consuming yet.
+func (nc *NotificationConsumer) Ready() bool {
+	select {
+	case <-nc.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitReady blocks until Ready reports true or ctx is done. Start or
+// ProcessTransactionally must be running for the consumer to become ready.
+//
+// Returns nil once the consumer is ready, or the context error if ctx is done first.
+func (nc *NotificationConsumer) WaitReady(ctx context.Context) error {
+	select {
+	case <-nc.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// markReady marks the consumer ready if session has been assigned any partitions.
+func (nc *NotificationConsumer) markReady(session sarama.ConsumerGroupSession) {
+	for _, partitions := range session.Claims() {
+		if len(partitions) > 0 {
+			nc.readyOnce.Do(func() { close(nc.ready) })
+			nc.logger.Infof("Kafka consumer ready | Group: %s | Member: %s", nc.config.ConsumerGroup, session.MemberID())
+			return
+		}
+	}
+}
+
+// Handle registers handler for messages whose Type is msgType, replacing any handler
+// previously registered for it.
+func (nc *NotificationConsumer) Handle(msgType string, handler Handler) {
+	nc.handlerMu.Lock()
+	defer nc.handlerMu.Unlock()
+	nc.handlers[msgType] = handler
+}
+
+// HandleTopic registers handler for messages from topic that have no handler for
+// their Type. The configured TopicPrefix is prepended to topic. It is not used for a
+// topic that the config assigns to several channels by mistake, since that topic does
+// not identify the message type; such messages need a handler registered with Handle.
+func (nc *NotificationConsumer) HandleTopic(topic string, handler Handler) {
+	nc.handlerMu.Lock()
+	defer nc.handlerMu.Unlock()
+	nc.topicHandlers[nc.config.Topic(topic)] = handler
+}
+
+// Start joins the consumer group and consumes from all subscribed topics until ctx is
+// cancelled or the consumer is closed. It rejoins the group after every rebalance.
+// A consumer created with NewAssignedConsumer consumes its assigned partitions instead.
+//
+// Returns nil when ctx is cancelled or the consumer is closed, or the error that
+// stopped consumption.
+func (nc *NotificationConsumer) Start(ctx context.Context) error {
+	if nc.assigned != nil {
+		return nc.consumeAssigned(ctx)
+	}
+	return nc.consume(ctx, &groupHandler{nc: nc})
+}
+
+// consume runs handler over the subscribed topics until ctx is cancelled or the
+// consumer is closed, rejoining the group after every rebalance.
+func (nc *NotificationConsumer) consume(ctx context.Context, handler sarama.ConsumerGroupHandler) error {
+	for {
+		if err := nc.group.Consume(ctx, nc.topics, handler); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
+			}
+			return fmt.Errorf("failed to consume from %s: %w", strings.Join(nc.topics, ","), err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// logErrors logs errors reported by the consumer group until it is closed.
+func (nc *NotificationConsumer) logErrors() {
+	for err := range nc.group.Errors() {
+		nc.logger.Errorf("Kafka consumer group error: %v", err)
+	}
+}
+
+// Close leaves the consumer group and releases all resources. A consumer created with
+// NewAssignedConsumer stops consuming, commits its offsets, and closes its partitions
+// instead.
+// It is safe to call multiple times; subsequent calls have no effect and return nil.
+//
+// Returns an error joining the failures to close the consumer group, its client, or the
+// dead-letter producer.
+func (nc *NotificationConsumer) Close() error {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	if nc.closed {
+		return nil
+	}
+
+	nc.closed = true
+	var errs []error
+	if nc.assigned != nil {
+		errs = append(errs, nc.closeAssigned()...)
+	} else {
+		if err := nc.group.Close(); err != nil {
+			nc.logger.Errorf("Error closing Kafka consumer group: %v", err)
+			errs = append(errs, fmt.Errorf("failed to close Kafka consumer group: %w", err))
+		}
+		// The group does not own the client, so it is closed even if the group failed to.
+		if err := nc.client.Close(); err != nil {
+			nc.logger.Errorf("Error closing Kafka client: %v", err)
+			errs = append(errs, fmt.Errorf("failed to close Kafka client: %w", err))
+		}
+		if len(errs) == 0 {
+			nc.logger.Infof("Kafka consumer closed successfully")
+		}
+	}
+	if nc.dlq != nil {
+		if err := nc.dlq.Close(); err != nil {
+			nc.logger.Errorf("Error closing dead-letter producer: %v", err)
+			errs = append(errs, fmt.Errorf("failed to close dead-letter producer: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sharedTopics returns the prefixed topics that cfg assigns to more than one channel.
+func sharedTopics(cfg config.KafkaConfig) map[string]bool {
+	channels := make(map[string]int, 4)
+	for _, topic := range []string{cfg.SMSTopic, cfg.EmailTopic, cfg.InAppTopic, cfg.PushTopic} {
+		if topic != "" {
+			channels[cfg.Topic(topic)]++
+		}
+	}
+
+	shared := make(map[string]bool)
+	for topic, count := range channels {
+		if count > 1 {
+			shared[topic] = true
+		}
+	}
+	return shared
+}
+
+// handlerFor returns the handler for a message of msgType from topic, wrapped in the
+// registered middlewares.
+func (nc *NotificationConsumer) handlerFor(msgType, topic string) (Handler, bool) {
+	nc.handlerMu.RLock()
+	defer nc.handlerMu.RUnlock()
+
+	handler, ok := nc.handlers[msgType]
+	if !ok {
+		if nc.sharedTopics[topic] {
+			return nil, false
+		}
+		if handler, ok = nc.topicHandlers[topic]; !ok {
+			return nil, false
+		}
+	}
+	return nc.wrap(handler), true
+}
+
+// process decodes msg and dispatches it to its handler. Messages that cannot be
+// decoded, have no handler, or were already processed are logged and skipped, as are
+// tombstones, messages whose expires_at header has passed, messages older than
+// DropIfOlderThan allows, poison messages (see WithFailureTracker), and messages
+// published by SelfTest. Messages whose handler fails or panics are dead-lettered when a
+// dead-letter topic is configured.
+//
+// Messages carrying the producer's type header are routed by that header, so messages
+// without a handler are skipped without decoding the JSON envelope. Messages without
+// the header are decoded first and routed by their Type field. With WithRouter, every
+// message is decoded first and routed by the router.
+func (nc *NotificationConsumer) process(ctx context.Context, msg *sarama.ConsumerMessage) {
+	if msg.Value == nil {
+		nc.logger.Infof("Skipping tombstone | Key: %s | Topic: %s", msg.Key, msg.Topic)
+		return
+	}
+	if isSelfTest(msg) {
+		messageID, _ := headerValue(msg.Headers, "message_id")
+		nc.logger.Infof("Skipping self-test message | ID: %s | Topic: %s", messageID, msg.Topic)
+		return
+	}
+	if expiresAt, expired := expiredAt(msg); expired {
+		messageID, _ := headerValue(msg.Headers, "message_id")
+		nc.logger.Infof("Skipping expired message | ID: %s | Topic: %s | Expired: %s", messageID, msg.Topic, expiresAt.Format(time.RFC3339))
+		if nc.dlq != nil && nc.dlqExpired {
+			nc.deadLetter(msg, ReasonExpired, nil)
+		}
+		return
+	}
+	if age, stale := nc.staleAge(msg); stale {
+		messageID, _ := headerValue(msg.Headers, "message_id")
+		nc.logger.Infof("Skipping stale message | ID: %s | Topic: %s | Age: %s", messageID, msg.Topic, age.Round(time.Second))
+		nc.recordStale(msg)
+		if nc.dlq != nil && nc.dlqExpired {
+			nc.deadLetter(msg, ReasonExpired, nil)
+		}
+		return
+	}
+
+	var handler Handler
+	msgType, routed := headerValue(msg.Headers, "type")
+	routed = routed && nc.router == nil
+	if routed {
+		var ok bool
+		if handler, ok = nc.handlerFor(msgType, msg.Topic); !ok {
+			messageID, _ := headerValue(msg.Headers, "message_id")
+			nc.logger.Errorf("No handler registered | ID: %s | Type: %s | Topic: %s", messageID, msgType, msg.Topic)
+			return
+		}
+	}
+
+	var notificationMsg dto.NotificationMessage
+	if err := decodeMessage(msg, &notificationMsg, nc.payloadCipher); err != nil {
+		nc.logger.Errorf("Failed to decode message | Topic: %s | Partition: %d | Offset: %d | Error: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		return
+	}
+
+	if !routed {
+		key := nc.route(&notificationMsg, msg.Headers)
+		var ok bool
+		if handler, ok = nc.handlerFor(key, msg.Topic); !ok {
+			nc.logger.Errorf("No handler registered | ID: %s | Type: %s | Topic: %s", notificationMsg.ID, key, msg.Topic)
+			return
+		}
+	}
+
+	dedupKey := notificationMsg.ID
+	if key, ok := headerValue(msg.Headers, "idempotency_key"); ok && key != "" {
+		dedupKey = "idempotency:" + key
+	}
+	if nc.seen != nil {
+		seen, err := nc.seen.Seen(ctx, dedupKey)
+		if err != nil {
+			nc.logger.Errorf("Dedup lookup failed, processing anyway | ID: %s | Key: %s | Error: %v", notificationMsg.ID, dedupKey, err)
+		} else if seen {
+			nc.logger.Infof("Skipping duplicate message | ID: %s | Key: %s | Type: %s | Topic: %s", notificationMsg.ID, dedupKey, notificationMsg.Type, msg.Topic)
+			return
+		}
+	}
+
+	if nc.poisoned(ctx, msg, &notificationMsg) {
+		return
+	}
+
+	ctx = withMessageInfo(ctx, MessageInfo{
+		Topic:         msg.Topic,
+		Partition:     msg.Partition,
+		Offset:        msg.Offset,
+		Timestamp:     msg.Timestamp,
+		SchemaVersion: schemaVersion(msg),
+	})
+	ctx, span := nc.startSpan(ctx, msg, &notificationMsg)
+	err := nc.invoke(ctx, handler, &notificationMsg)
+	endSpan(span, err)
+	if err != nil {
+		nc.logger.Errorf("Handler failed | ID: %s | Type: %s | Topic: %s | Error: %v", notificationMsg.ID, notificationMsg.Type, msg.Topic, err)
+		nc.recordFailure(ctx, notificationMsg.ID)
+		if nc.dlq != nil {
+			nc.deadLetter(msg, ReasonHandlerFailed, err)
+		}
+		return
+	}
+	nc.resetFailures(ctx, notificationMsg.ID)
+
+	if nc.seen != nil {
+		if err := nc.seen.Mark(ctx, dedupKey, nc.seenTTL); err != nil {
+			nc.logger.Errorf("Failed to mark message as processed | ID: %s | Key: %s | Error: %v", notificationMsg.ID, dedupKey, err)
+		}
+	}
+}
+
+// invoke runs handler for msg, turning a panic into an error wrapping ErrHandlerPanic
+// so that a bad message cannot stop the partition, and records its duration.
+func (nc *NotificationConsumer) invoke(ctx context.Context, handler Handler, msg *dto.NotificationMessage) (err error) {
+	defer nc.recordHandle(msg, time.Now(), &err)
+	defer recoverPanic(nc.logger, msg, &err)
+	return handler(ctx, msg)
+}
+
+// recordHandle records the duration since start and the outcome of the handler
+// invocation for msg that returned *err, if WithMetrics is set. It must be deferred
+// before recoverPanic so that it sees recovered panics.
+func (nc *NotificationConsumer) recordHandle(msg *dto.NotificationMessage, start time.Time, err *error) {
+	if nc.metrics == nil {
+		return
+	}
+	outcome := metrics.OutcomeSuccess
+	switch {
+	case errors.Is(*err, ErrHandlerPanic):
+		outcome = metrics.OutcomePanic
+	case *err != nil:
+		outcome = metrics.OutcomeError
+	}
+	nc.metrics.RecordHandle(msg.Type, outcome, time.Since(start))
+}
+
+// headerValue returns the value of the first header named key, and false if msg has
+// no such header.
+func headerValue(headers []*sarama.RecordHeader, key string) (string, bool) {
+	for _, header := range headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+// isSelfTest reports whether msg was published by the producer's SelfTest.
+func isSelfTest(msg *sarama.ConsumerMessage) bool {
+	selfTest, _ := headerValue(msg.Headers, producer.HeaderSelfTest)
+	return selfTest == "true"
+}
+
+// groupHandler adapts NotificationConsumer to sarama.ConsumerGroupHandler.
+type groupHandler struct {
+	nc *NotificationConsumer
+}
+
+// Setup is run at the beginning of a new session, before ConsumeClaim.
+func (h *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	h.nc.applyTopicOffsetReset(session)
+	h.nc.markReady(session)
+	return nil
+}
+
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited.
+func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim processes the messages of a single partition claim in order.
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	h.nc.consumeMessages(session, claim.Messages())
+	return nil
+}
+
+// claimSession is the part of sarama.ConsumerGroupSession used to track the offsets
+// of a partition, so that claimed and manually assigned partitions are consumed alike.
+type claimSession interface {
+	Context() context.Context
+	MarkMessage(msg *sarama.ConsumerMessage, metadata string)
+	Commit()
+}
+
+// consumeMessages processes the messages of a single partition in order until
+// messages is closed or the session context is done, marking each offset once its
+// handler returns. When auto-commit is disabled the offset is committed synchronously
+// after every message. With AtMostOnce the offset is marked and committed
+// synchronously before the handler runs.
+func (nc *NotificationConsumer) consumeMessages(session claimSession, messages <-chan *sarama.ConsumerMessage) {
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if nc.compacted {
+				nc.consumeCompacted(session, messages, msg)
+				continue
+			}
+			if nc.semantics == AtMostOnce {
+				session.MarkMessage(msg, "")
+				session.Commit()
+				nc.process(session.Context(), msg)
+				continue
+			}
+			nc.process(session.Context(), msg)
+			session.MarkMessage(msg, "")
+			if !nc.config.EnableAutoCommit {
+				session.Commit()
+			}
+		case <-session.Context().Done():
+			return
+		}
+	}
+}
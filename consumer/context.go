@@ -0,0 +1,40 @@
+package consumer
+
+import (
+	"context"
+	"time"
+)
+
+// MessageInfo describes where a consumed message came from. The time the producer
+// created the message is the CreatedAt of the NotificationMessage itself.
+type MessageInfo struct {
+	Topic     string    // Source topic the message was consumed from
+	Partition int32     // Partition the message was consumed from
+	Offset    int64     // Offset of the message within its partition
+	Timestamp time.Time // Record timestamp from the Kafka log
+	// SchemaVersion is the payload schema version from the schema_version header, 1
+	// for messages published before producers wrote it, or 0 if the header is not a
+	// positive integer.
+	SchemaVersion int
+}
+
+type messageInfoKey struct{}
+
+// withMessageInfo returns a copy of ctx carrying info.
+func withMessageInfo(ctx context.Context, info MessageInfo) context.Context {
+	return context.WithValue(ctx, messageInfoKey{}, info)
+}
+
+// MessageInfoFromContext returns the MessageInfo of the message being handled, and
+// false if ctx was not passed to a Handler by a NotificationConsumer.
+func MessageInfoFromContext(ctx context.Context) (MessageInfo, bool) {
+	info, ok := ctx.Value(messageInfoKey{}).(MessageInfo)
+	return info, ok
+}
+
+// TopicFromContext returns the source topic of the message being handled, or an empty
+// string if ctx was not passed to a Handler by a NotificationConsumer.
+func TopicFromContext(ctx context.Context) string {
+	info, _ := MessageInfoFromContext(ctx)
+	return info.Topic
+}
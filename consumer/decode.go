@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+)
+
+// maxDecompressedValueBytes is the largest decompressed size accepted for a message
+// value with a content-encoding header, so that a small malicious value cannot
+// exhaust memory when decoded.
+const maxDecompressedValueBytes = 16 * 1024 * 1024
+
+// decodeMessage unmarshals the NotificationMessage envelope of msg into v, first
+// decompressing the value if the producer set a content-encoding header. It then
+// decrypts the payload with aead if the producer set the encrypted header, and
+// converts its epoch-millisecond times back to RFC 3339 if the producer set the
+// time_format header.
+//
+// Returns an error if the content encoding is unsupported, the value cannot be
+// decompressed or decoded, it decompresses to more than maxDecompressedValueBytes, or
+// the payload is encrypted and aead is nil or cannot decrypt it.
+func decodeMessage(msg *sarama.ConsumerMessage, v *dto.NotificationMessage, aead cipher.AEAD) error {
+	value := msg.Value
+	if encoding, ok := headerValue(msg.Headers, "content-encoding"); ok {
+		if encoding != "gzip" {
+			return fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(value))
+		if err != nil {
+			return fmt.Errorf("failed to decompress value: %w", err)
+		}
+		if value, err = io.ReadAll(io.LimitReader(reader, maxDecompressedValueBytes+1)); err != nil {
+			return fmt.Errorf("failed to decompress value: %w", err)
+		}
+		if len(value) > maxDecompressedValueBytes {
+			return fmt.Errorf("decompressed value exceeds limit of %d bytes", maxDecompressedValueBytes)
+		}
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		return err
+	}
+
+	if encrypted, _ := headerValue(msg.Headers, kafkautil.HeaderEncrypted); encrypted == "true" {
+		if aead == nil {
+			return fmt.Errorf("payload is encrypted but no PayloadEncryptionKey is configured")
+		}
+		payload, err := kafkautil.DecryptPayload(aead, v.ID, v.Payload)
+		if err != nil {
+			return err
+		}
+		v.Payload = payload
+	}
+
+	if format, _ := headerValue(msg.Headers, kafkautil.HeaderTimeFormat); format == kafkautil.TimeFormatEpochMillis {
+		payload, err := kafkautil.RFC3339Times(v.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to convert payload times: %w", err)
+		}
+		v.Payload = payload
+	}
+	return nil
+}
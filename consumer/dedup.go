@@ -0,0 +1,125 @@
+package consumer
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultSeenCapacity is the number of message IDs remembered by the in-memory
+// SeenStore created when WithDeduplication is given a nil store.
+const defaultSeenCapacity = 10000
+
+// SeenStore records the IDs of processed messages so that redelivered messages can be
+// skipped. Implementations must be safe for concurrent use.
+type SeenStore interface {
+	// Seen reports whether id was marked and its TTL has not yet expired.
+	Seen(ctx context.Context, id string) (bool, error)
+	// Mark records id as processed for ttl.
+	Mark(ctx context.Context, id string, ttl time.Duration) error
+}
+
+// MemorySeenStore is an in-memory SeenStore that keeps the most recently marked IDs,
+// evicting the least recently marked one once capacity is reached. It only
+// deduplicates redeliveries within a single process.
+type MemorySeenStore struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Front is the most recently marked ID
+	mu       sync.Mutex
+}
+
+// seenEntry is the value stored in MemorySeenStore.order.
+type seenEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// NewMemorySeenStore creates a MemorySeenStore that remembers up to capacity IDs.
+// A capacity of zero or less uses a default of 10000.
+func NewMemorySeenStore(capacity int) *MemorySeenStore {
+	if capacity <= 0 {
+		capacity = defaultSeenCapacity
+	}
+	return &MemorySeenStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Seen reports whether id was marked within its TTL. Expired IDs are removed.
+func (s *MemorySeenStore) Seen(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[id]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(elem.Value.(*seenEntry).expiresAt) {
+		s.order.Remove(elem)
+		delete(s.entries, id)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Mark records id as processed for ttl, evicting the least recently marked ID if the
+// store is full.
+func (s *MemorySeenStore) Mark(_ context.Context, id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := s.entries[id]; ok {
+		elem.Value.(*seenEntry).expiresAt = expiresAt
+		s.order.MoveToFront(elem)
+		return nil
+	}
+
+	s.entries[id] = s.order.PushFront(&seenEntry{id: id, expiresAt: expiresAt})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*seenEntry).id)
+	}
+	return nil
+}
+
+// RedisCommands is the subset of Redis commands used by RedisSeenStore. Clients such as
+// go-redis need a small adapter, e.g. wrapping client.Exists(ctx, key).Result() and
+// client.Set(ctx, key, value, ttl).Err().
+type RedisCommands interface {
+	// Exists reports whether key exists.
+	Exists(ctx context.Context, key string) (bool, error)
+	// SetEx sets key to value with an expiry of ttl.
+	SetEx(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// RedisSeenStore is a SeenStore backed by Redis, which deduplicates redeliveries across
+// all consumer instances sharing the Redis server. Keys expire with the dedup TTL.
+type RedisSeenStore struct {
+	redis  RedisCommands
+	prefix string
+}
+
+// NewRedisSeenStore creates a RedisSeenStore that stores IDs under keys beginning with
+// prefix (e.g., "notifications:seen:").
+func NewRedisSeenStore(redis RedisCommands, prefix string) *RedisSeenStore {
+	return &RedisSeenStore{
+		redis:  redis,
+		prefix: prefix,
+	}
+}
+
+// Seen reports whether the key for id exists.
+func (s *RedisSeenStore) Seen(ctx context.Context, id string) (bool, error) {
+	return s.redis.Exists(ctx, s.prefix+id)
+}
+
+// Mark sets the key for id with an expiry of ttl.
+func (s *RedisSeenStore) Mark(ctx context.Context, id string, ttl time.Duration) error {
+	return s.redis.SetEx(ctx, s.prefix+id, "1", ttl)
+}
@@ -0,0 +1,89 @@
+package consumer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+)
+
+// Header keys written on messages produced to the dead-letter topic. The original
+// headers are preserved alongside them.
+const (
+	HeaderDLQReason          = "dlq_reason"           // Why the message was dead-lettered, e.g. ReasonHandlerFailed
+	HeaderDLQError           = "dlq_error"            // The error that caused it, if any
+	HeaderDLQSourceTopic     = "dlq_source_topic"     // Topic the message was consumed from
+	HeaderDLQSourcePartition = "dlq_source_partition" // Partition the message was consumed from
+	HeaderDLQSourceOffset    = "dlq_source_offset"    // Offset of the message in its source partition
+)
+
+// Reasons written to the dlq_reason header.
+const (
+	ReasonHandlerFailed = "handler_failed"
+	ReasonExpired       = "expired"
+	ReasonPoisonMessage = "poison_message"
+)
+
+// newDeadLetterProducer creates the producer used to write to the dead-letter topic.
+//
+// Returns an error if the brokers list is empty or the producer fails to initialize.
+func (nc *NotificationConsumer) newDeadLetterProducer() (sarama.SyncProducer, error) {
+	brokers, err := kafkautil.Brokers(nc.config)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaConfig := kafkautil.NewSaramaConfig(nc.config)
+	kafkaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	kafkaConfig.Producer.Retry.Max = 3
+	kafkaConfig.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, kafkaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+	return producer, nil
+}
+
+// deadLetter produces msg unchanged to the dead-letter topic, adding headers that
+// record reason, cause, and where the message came from. Failures are logged; the
+// message is not retried.
+func (nc *NotificationConsumer) deadLetter(msg *sarama.ConsumerMessage, reason string, cause error) {
+	headers := append(withoutDLQHeaders(msg.Headers),
+		sarama.RecordHeader{Key: []byte(HeaderDLQReason), Value: []byte(reason)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQSourceTopic), Value: []byte(msg.Topic)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQSourcePartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQSourceOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	if cause != nil {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(HeaderDLQError), Value: []byte(cause.Error())})
+	}
+
+	dlqMsg := &sarama.ProducerMessage{
+		Topic:   nc.dlqTopic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		dlqMsg.Key = sarama.ByteEncoder(msg.Key)
+	}
+
+	if _, _, err := nc.dlq.SendMessage(dlqMsg); err != nil {
+		nc.logger.Errorf("Failed to dead-letter message | Topic: %s | Partition: %d | Offset: %d | Reason: %s | Error: %v", msg.Topic, msg.Partition, msg.Offset, reason, err)
+		return
+	}
+	nc.logger.Infof("Message dead-lettered | Topic: %s | Partition: %d | Offset: %d | Reason: %s | DLQ: %s", msg.Topic, msg.Partition, msg.Offset, reason, nc.dlqTopic)
+}
+
+// withoutDLQHeaders returns a copy of headers without the dlq_* headers.
+func withoutDLQHeaders(headers []*sarama.RecordHeader) []sarama.RecordHeader {
+	kept := make([]sarama.RecordHeader, 0, len(headers)+5)
+	for _, header := range headers {
+		if header != nil && !strings.HasPrefix(string(header.Key), "dlq_") {
+			kept = append(kept, *header)
+		}
+	}
+	return kept
+}
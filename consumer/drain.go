@@ -0,0 +1,365 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// defaultDrainProgressInterval is how often DrainDLQ commits offsets and reports
+// progress when DrainOptions.ProgressInterval is zero.
+const defaultDrainProgressInterval = time.Second
+
+// DrainOptions configures DrainDLQ.
+type DrainOptions struct {
+	TargetTopic      string                             // Topic to republish to; empty republishes to each message's source topic
+	Filter           func(dto.NotificationMessage) bool // Selects the messages to republish; nil republishes all
+	Concurrency      int                                // Number of messages republished in parallel; values below 1 mean 1
+	OffsetGroup      string                             // Group the drain position is committed under; defaults to ConsumerGroup + "-dlq-drain"
+	ProgressInterval time.Duration                      // How often offsets are committed and OnProgress is called; defaults to one second
+	OnProgress       func(DrainProgress)                // Called periodically and once when the drain ends; may be nil
+}
+
+// DrainProgress reports how far a DrainDLQ call has got.
+type DrainProgress struct {
+	Processed int64 // Messages republished
+	Failed    int64 // Messages skipped because they could not be decoded or had no topic to republish to
+	Filtered  int64 // Messages rejected by the filter
+	Remaining int64 // Offsets not yet handled, up to the high-water marks at the start of the drain; zero once drained
+}
+
+// DrainDLQ is a concurrent, resumable ReprocessDLQ for dead-letter topics holding too
+// many messages to republish one at a time. It reads every partition of the
+// dead-letter topic up to the high-water mark at the time of the call and
+// republishes the messages accepted by opts.Filter with opts.Concurrency workers,
+// stripping their dlq_* headers as ReprocessDLQ does. Messages with the same key are
+// republished by the same worker, in order, so per-key ordering is preserved.
+//
+// The drain position is committed under opts.OffsetGroup every ProgressInterval and
+// when the drain ends, so a drain that is cancelled or crashes resumes where it left
+// off instead of starting over. An offset is committed only once every earlier message
+// of its partition has been handled, so messages are republished at least once: those
+// handled after the last commit before a crash are republished again. The first time a
+// group is used the drain starts at the oldest retained message.
+//
+// Returns the final progress, and the error that stopped the drain, if any. A failed
+// republish stops the drain without committing past the failed message, so the next
+// drain retries it. Requires WithDeadLetterTopic.
+func (nc *NotificationConsumer) DrainDLQ(ctx context.Context, opts DrainOptions) (DrainProgress, error) {
+	if nc.dlq == nil {
+		return DrainProgress{}, fmt.Errorf("dead-letter topic not configured")
+	}
+	if opts.TargetTopic != "" {
+		opts.TargetTopic = nc.config.Topic(opts.TargetTopic)
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.OffsetGroup == "" {
+		opts.OffsetGroup = nc.config.ConsumerGroup + "-dlq-drain"
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = defaultDrainProgressInterval
+	}
+
+	reader, err := NewPartitionReader(nc.config, nil)
+	if err != nil {
+		return DrainProgress{}, err
+	}
+	defer reader.Close()
+
+	offsets, err := sarama.NewOffsetManagerFromClient(opts.OffsetGroup, reader.client)
+	if err != nil {
+		return DrainProgress{}, fmt.Errorf("failed to create Kafka offset manager: %w", err)
+	}
+	defer func() {
+		if err := offsets.Close(); err != nil {
+			nc.logger.Errorf("Error closing Kafka offset manager: %v", err)
+		}
+	}()
+
+	d := &drain{nc: nc, opts: opts}
+	partitions, err := d.openPartitions(reader, offsets)
+	d.partitions = partitions
+	defer func() {
+		for _, p := range partitions {
+			p.pom.AsyncClose()
+		}
+	}()
+	if err != nil {
+		return DrainProgress{}, err
+	}
+
+	err = d.run(ctx, reader, offsets, partitions)
+	offsets.Commit()
+	progress := d.progress()
+	if opts.OnProgress != nil {
+		opts.OnProgress(progress)
+	}
+	nc.logger.Infof("Dead-letter drain finished | Topic: %s | Republished: %d | Failed: %d | Remaining: %d", nc.dlqTopic, progress.Processed, progress.Failed, progress.Remaining)
+	return progress, err
+}
+
+// drain is the state of a single DrainDLQ call.
+type drain struct {
+	nc         *NotificationConsumer
+	opts       DrainOptions
+	partitions []*drainPartition
+	processed  atomic.Int64
+	failed     atomic.Int64
+	filtered   atomic.Int64
+}
+
+// drainPartition tracks the handled offsets of one dead-letter partition, so that
+// only offsets below the first unhandled message are committed.
+type drainPartition struct {
+	partition int32
+	start     int64 // First offset to read
+	end       int64 // Offset to stop before
+	pom       sarama.PartitionOffsetManager
+
+	mu      sync.Mutex
+	pending []int64        // Offsets handed to workers, in order
+	done    map[int64]bool // Handled offsets still in pending
+	next    int64          // Offset after the longest handled prefix of the partition
+	read    bool           // Whether every message before end has been read
+}
+
+// drainJob is a message handed to a drain worker.
+type drainJob struct {
+	msg       *sarama.ConsumerMessage
+	partition *drainPartition
+}
+
+// openPartitions resolves where each dead-letter partition resumes from: the offset
+// committed under the drain's group, or the oldest retained offset if none has been
+// committed or it has since been deleted.
+//
+// Returns the partitions opened so far, which the caller must close, and an error if
+// the partitions or their offsets cannot be fetched.
+func (d *drain) openPartitions(reader *PartitionReader, offsets sarama.OffsetManager) ([]*drainPartition, error) {
+	ids, err := reader.client.Partitions(d.nc.dlqTopic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions for %s: %w", d.nc.dlqTopic, err)
+	}
+
+	partitions := make([]*drainPartition, 0, len(ids))
+	for _, id := range ids {
+		pom, err := offsets.ManagePartition(d.nc.dlqTopic, id)
+		if err != nil {
+			return partitions, fmt.Errorf("failed to manage offsets of %s/%d: %w", d.nc.dlqTopic, id, err)
+		}
+		p := &drainPartition{partition: id, pom: pom, done: make(map[int64]bool)}
+		partitions = append(partitions, p)
+
+		oldest, err := reader.client.GetOffset(d.nc.dlqTopic, id, sarama.OffsetOldest)
+		if err != nil {
+			return partitions, fmt.Errorf("failed to get oldest offset for %s/%d: %w", d.nc.dlqTopic, id, err)
+		}
+		if p.end, err = reader.client.GetOffset(d.nc.dlqTopic, id, sarama.OffsetNewest); err != nil {
+			return partitions, fmt.Errorf("failed to get high-water mark for %s/%d: %w", d.nc.dlqTopic, id, err)
+		}
+		p.start, _ = pom.NextOffset()
+		if p.start < oldest {
+			p.start = oldest
+		}
+		p.next = p.start
+	}
+	return partitions, nil
+}
+
+// run reads the partitions concurrently and republishes their messages with the
+// configured number of workers, committing offsets and reporting progress
+// periodically, until every partition is drained, ctx is cancelled, or a read or
+// republish fails.
+//
+// Returns the error that stopped the drain, if any.
+func (d *drain) run(ctx context.Context, reader *PartitionReader, offsets sarama.OffsetManager, partitions []*drainPartition) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	queues := make([]chan drainJob, d.opts.Concurrency)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan drainJob, 1)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range queues[i] {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := d.handle(job); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	reportDone := make(chan struct{})
+	var reporter sync.WaitGroup
+	reporter.Add(1)
+	go func() {
+		defer reporter.Done()
+		ticker := time.NewTicker(d.opts.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				offsets.Commit()
+				if d.opts.OnProgress != nil {
+					d.opts.OnProgress(d.progress())
+				}
+			case <-reportDone:
+				return
+			}
+		}
+	}()
+
+	var readers sync.WaitGroup
+	for _, p := range partitions {
+		if p.start >= p.end {
+			continue
+		}
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			rng := ReplayRange{Topic: d.nc.dlqTopic, Partition: p.partition, StartOffset: p.start, EndOffset: p.end}
+			_, err := reader.read(ctx, rng, func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+				p.dispatch(msg.Offset)
+				select {
+				case queues[d.worker(msg)] <- drainJob{msg: msg, partition: p}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err == nil {
+				p.finishRead()
+			} else if !errors.Is(err, context.Canceled) {
+				fail(err)
+			}
+		}()
+	}
+
+	readers.Wait()
+	for _, queue := range queues {
+		close(queue)
+	}
+	workers.Wait()
+	close(reportDone)
+	reporter.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// worker returns the index of the worker that republishes msg. Messages with the same
+// key go to the same worker, so they are republished in order.
+func (d *drain) worker(msg *sarama.ConsumerMessage) int {
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(d.opts.Concurrency))
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(msg.Key)
+	return int(h.Sum32() % uint32(d.opts.Concurrency))
+}
+
+// handle republishes the message of job and marks it handled, unless republishing
+// fails.
+//
+// Returns the republish error, if any.
+func (d *drain) handle(job drainJob) error {
+	outcome, err := d.nc.reprocessMessage(job.msg, d.opts.TargetTopic, d.opts.Filter)
+	switch outcome {
+	case reprocessRepublished:
+		d.processed.Add(1)
+	case reprocessFiltered:
+		d.filtered.Add(1)
+	case reprocessSkipped:
+		d.failed.Add(1)
+	case reprocessFailed:
+		return err
+	}
+	job.partition.complete(job.msg.Offset)
+	return nil
+}
+
+// progress returns a snapshot of the drain's progress.
+func (d *drain) progress() DrainProgress {
+	p := DrainProgress{
+		Processed: d.processed.Load(),
+		Failed:    d.failed.Load(),
+		Filtered:  d.filtered.Load(),
+	}
+	for _, partition := range d.partitions {
+		p.Remaining += partition.remaining()
+	}
+	return p
+}
+
+// remaining returns the number of offsets of the partition left to handle: none once
+// every message has been read and handled, even if the offsets before end include
+// transaction markers or compacted records that never arrive as messages, and
+// otherwise the offsets from the first unhandled message to end.
+func (p *drainPartition) remaining() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.read && len(p.pending) == 0 {
+		return 0
+	}
+	return max(p.end-p.next, 0)
+}
+
+// finishRead records that every message of the partition before end has been read.
+func (p *drainPartition) finishRead() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.read = true
+}
+
+// dispatch records that offset has been handed to a worker.
+func (p *drainPartition) dispatch(offset int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, offset)
+}
+
+// complete records that offset has been handled and marks the offset after the
+// longest handled prefix of the pending offsets for commit.
+func (p *drainPartition) complete(offset int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[offset] = true
+	next := int64(-1)
+	for len(p.pending) > 0 && p.done[p.pending[0]] {
+		delete(p.done, p.pending[0])
+		next = p.pending[0] + 1
+		p.pending = p.pending[1:]
+	}
+	if next >= 0 {
+		p.next = next
+		p.pom.MarkOffset(next, "")
+	}
+}
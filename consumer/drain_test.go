@@ -0,0 +1,169 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/dawit-go/notification-kafka-lib/config"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// drainProducer is a dead-letter sarama.SyncProducer that runs gate with the DLQ
+// offset of each republished message before passing it to the mock, outside the
+// mock's lock, and records the offsets republished.
+type drainProducer struct {
+	*mocks.SyncProducer
+	gate func(offset int64)
+
+	mu   sync.Mutex
+	sent map[int64]bool
+}
+
+func (p *drainProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	value, _ := msg.Value.Encode()
+	var notificationMsg dto.NotificationMessage
+	_ = json.Unmarshal(value, &notificationMsg)
+	offset, _ := strconv.ParseInt(notificationMsg.ID, 10, 64)
+
+	p.gate(offset)
+	partition, kafkaOffset, err := p.SyncProducer.SendMessage(msg)
+	p.mu.Lock()
+	p.sent[offset] = err == nil
+	p.mu.Unlock()
+	return partition, kafkaOffset, err
+}
+
+// handled reports whether every offset below offset has been republished.
+func (p *drainProducer) handled(offset int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for o := range offset {
+		if !p.sent[o] {
+			return false
+		}
+	}
+	return true
+}
+
+// drainOffsets is a sarama.OffsetManager whose commits do nothing.
+type drainOffsets struct {
+	sarama.OffsetManager
+}
+
+func (drainOffsets) Commit() {}
+
+// markRecorder is a sarama.PartitionOffsetManager recording the offsets marked for
+// commit.
+type markRecorder struct {
+	sarama.PartitionOffsetManager
+	mark func(offset int64)
+}
+
+func (r *markRecorder) MarkOffset(offset int64, _ string) {
+	r.mark(offset)
+}
+
+func TestDrainCommitsOnlyContiguousHandledOffsets(t *testing.T) {
+	kafkaConfig := mocks.NewTestConfig()
+	kafkaConfig.Consumer.MaxWaitTime = 10 * time.Millisecond
+
+	// Keyless messages are spread by offset, so with two workers offsets 0 and 2 go
+	// to one worker and offsets 1 and 3 to the other.
+	consumer := mocks.NewConsumer(t, kafkaConfig)
+	pc := consumer.ExpectConsumePartition("dlq", 0, 0)
+	for offset := range 4 {
+		value, err := json.Marshal(dto.NotificationMessage{ID: strconv.Itoa(offset), Type: "email", Payload: json.RawMessage("{}")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pc.YieldMessage(&sarama.ConsumerMessage{Value: value})
+	}
+
+	mock := mocks.NewSyncProducer(t, mocks.NewTestConfig())
+	for range 4 {
+		mock.ExpectSendMessageAndSucceed()
+	}
+	release := make(chan struct{})
+	producer := &drainProducer{SyncProducer: mock, sent: make(map[int64]bool), gate: func(offset int64) {
+		if offset == 0 {
+			<-release // Hold offset 0 until the other worker has handled 1 and 3
+		}
+	}}
+
+	var marksMu sync.Mutex
+	var marks []int64
+	partition := &drainPartition{partition: 0, start: 0, end: 4, next: 0, done: make(map[int64]bool)}
+	partition.pom = &markRecorder{mark: func(offset int64) {
+		if !producer.handled(offset) {
+			t.Errorf("offset %d marked for commit before every earlier message was handled", offset)
+		}
+		marksMu.Lock()
+		marks = append(marks, offset)
+		marksMu.Unlock()
+	}}
+
+	nc := &NotificationConsumer{dlq: producer, dlqTopic: "dlq", logger: loggerOrNop(nil)}
+	d := &drain{
+		nc:         nc,
+		opts:       DrainOptions{TargetTopic: "target", Concurrency: 2, ProgressInterval: time.Hour},
+		partitions: []*drainPartition{partition},
+	}
+	reader := &PartitionReader{
+		client:   &gapClient{config: kafkaConfig, hwm: 4},
+		consumer: &gapConsumer{Consumer: consumer, hwm: 4},
+		logger:   loggerOrNop(nil),
+		config:   config.KafkaConfig{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.run(ctx, reader, drainOffsets{}, d.partitions)
+	}()
+
+	for {
+		producer.mu.Lock()
+		laterHandled := producer.sent[1] && producer.sent[3]
+		producer.mu.Unlock()
+		if laterHandled {
+			break
+		}
+		if ctx.Err() != nil {
+			t.Fatal("offsets 1 and 3 were not republished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	marksMu.Lock()
+	if len(marks) > 0 {
+		t.Errorf("offsets %v marked while offset 0 was unhandled, want none", marks)
+	}
+	marksMu.Unlock()
+	if got := d.progress().Remaining; got != 4 {
+		t.Errorf("Remaining = %d while offset 0 was unhandled, want 4", got)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("run returned error %v, want nil", err)
+	}
+	if want := []int64{2, 4}; len(marks) != len(want) || marks[0] != want[0] || marks[1] != want[1] {
+		t.Errorf("marked offsets %v, want %v", marks, want)
+	}
+	if got := d.progress(); got.Processed != 4 || got.Remaining != 0 {
+		t.Errorf("progress = %+v, want 4 processed and none remaining", got)
+	}
+	if err := mock.Close(); err != nil {
+		t.Fatalf("closing mock producer: %v", err)
+	}
+	if err := consumer.Close(); err != nil {
+		t.Fatalf("closing mock consumer: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/metrics"
+)
+
+// expiredAt returns the expiry written by the producer to the expires_at header of
+// msg, and whether it has passed. Messages without a valid header never expire.
+func expiredAt(msg *sarama.ConsumerMessage) (time.Time, bool) {
+	value, ok := headerValue(msg.Headers, "expires_at")
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, time.Now().After(expiresAt)
+}
+
+// publishedAt returns when msg was published: the producer's timestamp header, or the
+// broker timestamp if the header is missing or invalid.
+func publishedAt(msg *sarama.ConsumerMessage) time.Time {
+	if value, ok := headerValue(msg.Headers, "timestamp"); ok {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return msg.Timestamp
+}
+
+// staleAge returns the age of msg, and whether it exceeds the DropIfOlderThan limit.
+// Messages without any timestamp are never stale.
+func (nc *NotificationConsumer) staleAge(msg *sarama.ConsumerMessage) (time.Duration, bool) {
+	if nc.maxAge <= 0 {
+		return 0, false
+	}
+	published := publishedAt(msg)
+	if published.IsZero() {
+		return 0, false
+	}
+	age := time.Since(published)
+	return age, age > nc.maxAge
+}
+
+// recordStale reports a stale msg to the metrics recorder, if it is a
+// metrics.StaleRecorder.
+func (nc *NotificationConsumer) recordStale(msg *sarama.ConsumerMessage) {
+	recorder, ok := nc.metrics.(metrics.StaleRecorder)
+	if !ok {
+		return
+	}
+	msgType, _ := headerValue(msg.Headers, "type")
+	recorder.RecordStale(msgType, msg.Topic)
+}
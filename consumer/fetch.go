@@ -0,0 +1,103 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// FetchMessages reads up to maxMessages notification messages from every partition of topic,
+// returning when maxMessages messages have been read, the timeout elapses, or ctx is done.
+// Reading starts at the oldest offset when the offset reset policy of topic is
+// "earliest" and at the high-water mark otherwise. Temporary partition consumers are created for the call
+// and closed before it returns; no offsets are committed. The configured TopicPrefix
+// is prepended to topic.
+//
+// It is intended for integration tests and one-off scripts.
+//
+// Returns the messages read so far, and an error if the partitions cannot be consumed,
+// a message cannot be decoded, or ctx is cancelled. Reaching the timeout is not an error.
+func (pr *PartitionReader) FetchMessages(ctx context.Context, topic string, maxMessages int, timeout time.Duration) ([]dto.NotificationMessage, error) {
+	topic = pr.config.Topic(topic)
+
+	partitions, err := pr.client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions for %s: %w", topic, err)
+	}
+
+	start := initialOffset(pr.config.OffsetReset(topic))
+
+	consumers := make(map[int32]sarama.PartitionConsumer, len(partitions))
+	defer func() {
+		for partition, pc := range consumers {
+			if err := pc.Close(); err != nil {
+				pr.logger.Errorf("Error closing partition consumer for %s/%d: %v", topic, partition, err)
+			}
+		}
+	}()
+
+	for _, partition := range partitions {
+		pc, err := pr.consumer.ConsumePartition(topic, partition, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+		}
+		consumers[partition] = pc
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	received := make(chan *sarama.ConsumerMessage)
+	failed := make(chan error, len(consumers))
+	var wg sync.WaitGroup
+	for _, pc := range consumers {
+		wg.Add(1)
+		go func(pc sarama.PartitionConsumer) {
+			defer wg.Done()
+			for {
+				select {
+				case <-fetchCtx.Done():
+					return
+				case err := <-pc.Errors():
+					failed <- err
+					return
+				case msg, ok := <-pc.Messages():
+					if !ok {
+						return
+					}
+					select {
+					case received <- msg:
+					case <-fetchCtx.Done():
+						return
+					}
+				}
+			}
+		}(pc)
+	}
+	defer wg.Wait()
+	defer cancel()
+
+	messages := make([]dto.NotificationMessage, 0, maxMessages)
+	for len(messages) < maxMessages {
+		select {
+		case <-fetchCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return messages, err
+			}
+			return messages, nil
+		case err := <-failed:
+			return messages, fmt.Errorf("failed to read %s: %w", topic, err)
+		case msg := <-received:
+			var notificationMsg dto.NotificationMessage
+			if err := decodeMessage(msg, &notificationMsg, pr.payloadCipher); err != nil {
+				return messages, fmt.Errorf("failed to decode message at %s/%d offset %d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+			}
+			messages = append(messages, notificationMsg)
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,14 @@
+package consumer
+
+import (
+	"github.com/dawit-go/notification-kafka-lib/producer"
+	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+)
+
+// loggerOrNop returns logger as a producer.Logger, or a no-op logger if it is nil.
+func loggerOrNop(logger utils.Logger) producer.Logger {
+	if logger == nil {
+		return producer.NopLogger()
+	}
+	return logger
+}
@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/producer"
+	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+)
+
+// ErrHandlerPanic is the handler error recorded when a handler panics.
+var ErrHandlerPanic = errors.New("handler panicked")
+
+// Middleware wraps a Handler with cross-cutting behavior such as logging, metrics,
+// or tracing.
+type Middleware func(next Handler) Handler
+
+// Use adds middlewares around every registered handler, including handlers
+// registered later. Middlewares run in the order they are added, so the first one
+// added is the outermost.
+func (nc *NotificationConsumer) Use(middlewares ...Middleware) {
+	nc.handlerMu.Lock()
+	defer nc.handlerMu.Unlock()
+	nc.middlewares = append(nc.middlewares, middlewares...)
+}
+
+// wrap applies the registered middlewares to handler. It must be called with
+// nc.handlerMu held.
+func (nc *NotificationConsumer) wrap(handler Handler) Handler {
+	for i := len(nc.middlewares) - 1; i >= 0; i-- {
+		handler = nc.middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recovery returns a middleware that recovers from a panic in the handler, logs it
+// with the message ID and stack trace, and returns an error wrapping ErrHandlerPanic
+// instead. The consumer always recovers handler panics; Recovery lets middlewares
+// outside it, such as Logging, observe the panic as an error. A nil logger is
+// replaced with a no-op logger.
+func Recovery(logger utils.Logger) Middleware {
+	log := loggerOrNop(logger)
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *dto.NotificationMessage) (err error) {
+			defer recoverPanic(log, msg, &err)
+			return next(ctx, msg)
+		}
+	}
+}
+
+// recoverPanic recovers a panic in the handler for msg, logs it with the stack trace,
+// and sets *err to an error wrapping ErrHandlerPanic. It must be called directly by
+// defer.
+func recoverPanic(log producer.Logger, msg *dto.NotificationMessage, err *error) {
+	if r := recover(); r != nil {
+		log.Errorf("Handler panicked | ID: %s | Type: %s | Panic: %v\n%s", msg.ID, msg.Type, r, debug.Stack())
+		*err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+	}
+}
+
+// Logging returns a middleware that logs the outcome and duration of every handler
+// invocation. A nil logger is replaced with a no-op logger.
+func Logging(logger utils.Logger) Middleware {
+	log := loggerOrNop(logger)
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *dto.NotificationMessage) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			info, _ := MessageInfoFromContext(ctx)
+			if err != nil {
+				log.Errorf("Message handling failed | ID: %s | Type: %s | Topic: %s | Partition: %d | Offset: %d | Duration: %s | Error: %v", msg.ID, msg.Type, info.Topic, info.Partition, info.Offset, time.Since(start), err)
+				return err
+			}
+			log.Infof("Message handled | ID: %s | Type: %s | Topic: %s | Partition: %d | Offset: %d | Duration: %s", msg.ID, msg.Type, info.Topic, info.Partition, info.Offset, time.Since(start))
+			return nil
+		}
+	}
+}
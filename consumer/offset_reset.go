@@ -0,0 +1,68 @@
+package consumer
+
+import (
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// initialOffset returns the offset a partition starts from under the offset reset
+// policy: sarama.OffsetOldest for "earliest" and sarama.OffsetNewest otherwise.
+func initialOffset(policy string) int64 {
+	if strings.EqualFold(policy, "earliest") {
+		return sarama.OffsetOldest
+	}
+	return sarama.OffsetNewest
+}
+
+// applyTopicOffsetReset starts the claimed partitions of session that have no
+// committed offset at the position selected by their topic's TopicOffsetReset
+// override. Sarama applies a single offset reset policy to the whole group, so the
+// override is applied by marking the oldest or newest offset of those partitions
+// before their claims start. Partitions with a committed offset resume from it.
+// Errors are logged and leave the partitions at the group's AutoOffsetReset.
+func (nc *NotificationConsumer) applyTopicOffsetReset(session sarama.ConsumerGroupSession) {
+	if len(nc.config.TopicOffsetReset) == 0 {
+		return
+	}
+
+	groupInitial := nc.client.Config().Consumer.Offsets.Initial
+	overridden := make(map[string][]int32)
+	for topic, partitions := range session.Claims() {
+		if initialOffset(nc.config.OffsetReset(topic)) != groupInitial {
+			overridden[topic] = partitions
+		}
+	}
+	if len(overridden) == 0 {
+		return
+	}
+
+	coordinator, err := nc.client.Coordinator(nc.config.ConsumerGroup)
+	if err != nil {
+		nc.logger.Errorf("Failed to apply topic offset reset, coordinator unavailable | Group: %s | Error: %v", nc.config.ConsumerGroup, err)
+		return
+	}
+	request := sarama.NewOffsetFetchRequest(nc.client.Config().Version, nc.config.ConsumerGroup, overridden)
+	response, err := coordinator.FetchOffset(request)
+	if err != nil {
+		nc.logger.Errorf("Failed to apply topic offset reset, committed offsets unavailable | Group: %s | Error: %v", nc.config.ConsumerGroup, err)
+		return
+	}
+
+	for topic, partitions := range overridden {
+		policy := nc.config.OffsetReset(topic)
+		for _, partition := range partitions {
+			block := response.GetBlock(topic, partition)
+			if block == nil || block.Err != sarama.ErrNoError || block.Offset >= 0 {
+				continue
+			}
+			offset, err := nc.client.GetOffset(topic, partition, initialOffset(policy))
+			if err != nil {
+				nc.logger.Errorf("Failed to apply topic offset reset | Topic: %s | Partition: %d | Error: %v", topic, partition, err)
+				continue
+			}
+			session.MarkOffset(topic, partition, offset, "")
+			nc.logger.Infof("Starting partition without committed offset at %s | Topic: %s | Partition: %d | Offset: %d", policy, topic, partition, offset)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package consumer
+
+import (
+	"time"
+
+	"github.com/dawit-go/notification-kafka-lib/metrics"
+)
+
+// Option configures a NotificationConsumer at construction time.
+type Option func(*NotificationConsumer)
+
+// WithDeadLetterTopic produces messages whose handler fails to topic, unchanged and
+// with dlq_* headers recording why and where they came from. The configured
+// TopicPrefix is prepended to topic. See WithExpiredToDeadLetter to also dead-letter
+// expired messages.
+func WithDeadLetterTopic(topic string) Option {
+	return func(nc *NotificationConsumer) {
+		nc.dlqTopic = nc.config.Topic(topic)
+	}
+}
+
+// WithExpiredToDeadLetter produces messages skipped because their expires_at header
+// has passed, or because they are older than DropIfOlderThan allows, to the
+// dead-letter topic instead of dropping them. It has no effect without
+// WithDeadLetterTopic.
+func WithExpiredToDeadLetter() Option {
+	return func(nc *NotificationConsumer) {
+		nc.dlqExpired = true
+	}
+}
+
+// DropIfOlderThan skips messages published more than maxAge ago, by the producer's
+// timestamp header or else the broker timestamp, before their handler runs. Use it so
+// that a consumer catching up on a large lag after an outage does not deliver OTPs
+// and alerts that are no longer useful; unlike the per-message expires_at header, it
+// applies to every message. Skipped messages are committed like handled ones, are
+// dead-lettered with ReasonExpired when WithExpiredToDeadLetter is set, and are
+// counted by a WithMetrics recorder that implements metrics.StaleRecorder. A maxAge of
+// zero or less disables the check.
+func DropIfOlderThan(maxAge time.Duration) Option {
+	return func(nc *NotificationConsumer) {
+		nc.maxAge = maxAge
+	}
+}
+
+// DeliverySemantics selects when a NotificationConsumer commits a message's offset
+// relative to running its handler.
+type DeliverySemantics int
+
+const (
+	// AtLeastOnce commits the offset after the handler returns. A crash mid-handler
+	// redelivers the message, so handlers may see it twice. This is the default.
+	AtLeastOnce DeliverySemantics = iota
+	// AtMostOnce commits the offset synchronously before the handler runs. A crash
+	// mid-handler loses the message instead of redelivering it. Use it only for
+	// best-effort channels, such as marketing push, where a duplicate is worse than a
+	// missed message.
+	AtMostOnce
+)
+
+// WithDeliverySemantics sets when offsets are committed. See AtLeastOnce and
+// AtMostOnce for the tradeoff; ProcessTransactionally provides exactly-once processing.
+func WithDeliverySemantics(semantics DeliverySemantics) Option {
+	return func(nc *NotificationConsumer) {
+		nc.semantics = semantics
+	}
+}
+
+// WithDeduplication skips messages whose ID was already processed within ttl, which
+// prevents duplicate sends when a rebalance redelivers messages. Messages published
+// with an idempotency key are deduplicated by that key instead, so the handler runs
+// once per key even across distinct message IDs. IDs and keys are marked only after
+// their handler succeeds. A nil store uses an in-memory MemorySeenStore; use a
+// shared store such as RedisSeenStore to deduplicate across consumer instances.
+func WithDeduplication(store SeenStore, ttl time.Duration) Option {
+	return func(nc *NotificationConsumer) {
+		if store == nil {
+			store = NewMemorySeenStore(defaultSeenCapacity)
+		}
+		nc.seen = store
+		nc.seenTTL = ttl
+	}
+}
+
+// WithFailureTracker recognizes poison messages: messages whose processing has
+// failed maxFailures times, counted by message ID in tracker across consumer group
+// sessions, rebalances, and, with a persistent tracker, restarts. When a poison
+// message is redelivered, it is skipped before its handler or any Retry middleware
+// runs, committed, and dead-lettered with ReasonPoisonMessage when
+// WithDeadLetterTopic is set.
+//
+// A failure is counted each time the handler returns an error or panics, and the
+// count is cleared once the message is handled successfully. A nil tracker uses an
+// in-memory MemoryFailureTracker; a maxFailures below 1 means 1.
+func WithFailureTracker(tracker FailureTracker, maxFailures int) Option {
+	return func(nc *NotificationConsumer) {
+		if tracker == nil {
+			tracker = NewMemoryFailureTracker(defaultFailureCapacity)
+		}
+		nc.failures = tracker
+		nc.maxFailures = max(maxFailures, 1)
+	}
+}
+
+// WithCompaction gives the consumer the read-side semantics of a compacted topic:
+// among the messages already buffered for a partition (up to 500 at a time), only
+// the last message for each key is handled, and earlier ones with the same key are
+// skipped as superseded. Keyless messages are always handled. Use it for topics
+// keyed by entity, such as in-app notifications keyed by user, to avoid processing
+// stale notifications while catching up after downtime.
+//
+// Handled messages keep their relative offset order, so each key's latest message is
+// still processed in partition order, but the order between keys follows their latest
+// messages. Offsets are marked as messages are handled, and the offset of the batch's
+// last message, which covers the skipped ones, once the whole batch has been handled
+// (before it with AtMostOnce), so skipped messages are never redelivered on their own.
+func WithCompaction() Option {
+	return func(nc *NotificationConsumer) {
+		nc.compacted = true
+	}
+}
+
+// WithMetrics records the wall time of every handler invocation to recorder, labeled
+// by message type and outcome: metrics.OutcomeSuccess, metrics.OutcomeError, or
+// metrics.OutcomePanic. The time covers the registered middlewares as well as the
+// handler, but not decoding, deduplication, or dead-lettering. If recorder is also a
+// metrics.StaleRecorder, messages skipped by DropIfOlderThan are recorded to it.
+func WithMetrics(recorder metrics.HandlerRecorder) Option {
+	return func(nc *NotificationConsumer) {
+		nc.metrics = recorder
+	}
+}
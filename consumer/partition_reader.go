@@ -0,0 +1,239 @@
+// Package consumer provides a consumer group consumer and standalone readers for
+// notification topics.
+package consumer
+
+import (
+	"context"
+	"crypto/cipher"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/config"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+	"github.com/dawit-go/notification-kafka-lib/producer"
+	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+)
+
+// gapFetchWaits is how many fetch waits (Consumer.MaxWaitTime) Read waits without a
+// message before concluding that the offsets left below the end of its range hold no
+// messages, such as transaction markers or records removed by compaction. A fetch
+// with messages to return comes back at once, so only an empty fetch lasts that long.
+const gapFetchWaits = 4
+
+// ReplayFunc is invoked for every message read by a PartitionReader.
+// Returning an error stops the replay.
+type ReplayFunc func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+// ReplayRange describes the part of a single topic partition to replay.
+type ReplayRange struct {
+	Topic       string    // Topic to read from
+	Partition   int32     // Partition to read from
+	StartOffset int64     // First offset to read; sarama.OffsetOldest starts at the oldest retained message
+	StartTime   time.Time // When set, overrides StartOffset with the first offset at or after this time
+	EndOffset   int64     // Offset to stop before; zero reads up to the high-water mark at the time Read is called
+	EndTime     time.Time // Stop at the first message with a timestamp after EndTime; zero means no time bound
+}
+
+// PartitionReader reads a bounded range of messages from a single topic partition,
+// independent of any consumer group. It is intended for replaying notifications
+// during incident recovery and never commits offsets.
+type PartitionReader struct {
+	client   sarama.Client
+	consumer sarama.Consumer
+	logger   producer.Logger
+	config   config.KafkaConfig
+	mu       sync.Mutex
+	closed   bool
+
+	payloadCipher cipher.AEAD // Decrypts encrypted payloads in FetchMessages; nil without PayloadEncryptionKey
+}
+
+// NewPartitionReader creates a new PartitionReader using the provided KafkaConfig
+// and logger. A nil logger is replaced with a no-op logger.
+//
+// Returns an error if the brokers list is empty, the PayloadEncryptionKey is invalid,
+// or the Kafka client fails to initialize.
+func NewPartitionReader(cfg config.KafkaConfig, logger utils.Logger) (*PartitionReader, error) {
+	brokers, err := kafkautil.Brokers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	payloadCipher, err := kafkautil.NewPayloadCipher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaConfig := kafkautil.NewSaramaConfig(cfg)
+	kafkaConfig.Consumer.Return.Errors = true
+	applyFetchConfig(kafkaConfig, cfg)
+
+	client, err := sarama.NewClient(brokers, kafkaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+
+	return &PartitionReader{
+		client:        client,
+		consumer:      consumer,
+		logger:        loggerOrNop(logger),
+		config:        cfg,
+		payloadCipher: payloadCipher,
+	}, nil
+}
+
+// Read replays the messages in rng, invoking fn for each one in offset order.
+// Reading stops when EndOffset or EndTime is reached, when fn returns an error,
+// or when ctx is cancelled. The configured TopicPrefix is prepended to rng.Topic.
+//
+// The last offsets before the end of the range may hold no messages, because they
+// are transaction markers or were removed by compaction. Once the partition's
+// high-water mark has reached the end, Read therefore also stops when no message
+// arrives for several fetch waits (see Consumer.MaxWaitTime), instead of waiting for
+// an offset that will never be delivered.
+//
+// Returns the number of messages passed to fn and any error that stopped the replay.
+func (pr *PartitionReader) Read(ctx context.Context, rng ReplayRange, fn ReplayFunc) (int, error) {
+	rng.Topic = pr.config.Topic(rng.Topic)
+	return pr.read(ctx, rng, fn)
+}
+
+// read implements Read for rng.Topic as is, for topics whose name already includes
+// the TopicPrefix, such as the dead-letter topic.
+func (pr *PartitionReader) read(ctx context.Context, rng ReplayRange, fn ReplayFunc) (int, error) {
+	end, err := pr.client.GetOffset(rng.Topic, rng.Partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get high-water mark for %s/%d: %w", rng.Topic, rng.Partition, err)
+	}
+	if rng.EndOffset > 0 && rng.EndOffset < end {
+		end = rng.EndOffset
+	}
+
+	start := rng.StartOffset
+	if !rng.StartTime.IsZero() {
+		if start, err = pr.offsetForTime(rng.Topic, rng.Partition, rng.StartTime, end); err != nil {
+			return 0, err
+		}
+	} else if start == sarama.OffsetOldest {
+		if start, err = pr.client.GetOffset(rng.Topic, rng.Partition, sarama.OffsetOldest); err != nil {
+			return 0, fmt.Errorf("failed to get oldest offset for %s/%d: %w", rng.Topic, rng.Partition, err)
+		}
+	}
+	if start == sarama.OffsetNewest || start >= end {
+		return 0, nil
+	}
+
+	pc, err := pr.consumer.ConsumePartition(rng.Topic, rng.Partition, start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume %s/%d from offset %d: %w", rng.Topic, rng.Partition, start, err)
+	}
+	defer func() {
+		if err := pc.Close(); err != nil {
+			pr.logger.Errorf("Error closing partition consumer for %s/%d: %v", rng.Topic, rng.Partition, err)
+		}
+	}()
+
+	idle := gapFetchWaits * pr.client.Config().Consumer.MaxWaitTime
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		case err := <-pc.Errors():
+			return count, fmt.Errorf("failed to read %s/%d: %w", rng.Topic, rng.Partition, err)
+		case <-timer.C:
+			if pc.HighWaterMarkOffset() >= end {
+				return count, nil
+			}
+			timer.Reset(idle)
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return count, nil
+			}
+			if msg.Offset >= end {
+				return count, nil
+			}
+			if !rng.EndTime.IsZero() && msg.Timestamp.After(rng.EndTime) {
+				return count, nil
+			}
+			if err := fn(ctx, msg); err != nil {
+				return count, fmt.Errorf("replay stopped at offset %d: %w", msg.Offset, err)
+			}
+			count++
+			if msg.Offset+1 >= end {
+				return count, nil
+			}
+			timer.Reset(idle)
+		}
+	}
+}
+
+// OffsetsForTime resolves t to a starting offset for every partition of topic,
+// using the broker's offset-for-timestamp lookup. Each offset is the first one
+// whose timestamp is at or after t; partitions with no such message map to their
+// high-water mark, so nothing is read from them. The configured TopicPrefix is
+// prepended to topic.
+//
+// Returns an error if the partitions or offsets cannot be fetched.
+func (pr *PartitionReader) OffsetsForTime(topic string, t time.Time) (map[int32]int64, error) {
+	topic = pr.config.Topic(topic)
+
+	partitions, err := pr.client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions for %s: %w", topic, err)
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		hwm, err := pr.client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get high-water mark for %s/%d: %w", topic, partition, err)
+		}
+		if offsets[partition], err = pr.offsetForTime(topic, partition, t, hwm); err != nil {
+			return nil, err
+		}
+	}
+	return offsets, nil
+}
+
+// offsetForTime returns the first offset of topic/partition with a timestamp at or
+// after t, or hwm if every message is older than t.
+func (pr *PartitionReader) offsetForTime(topic string, partition int32, t time.Time, hwm int64) (int64, error) {
+	offset, err := pr.client.GetOffset(topic, partition, t.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get offset for time %s on %s/%d: %w", t.Format(time.RFC3339), topic, partition, err)
+	}
+	if offset < 0 {
+		return hwm, nil
+	}
+	return offset, nil
+}
+
+// Close closes the underlying consumer and client.
+// It is safe to call multiple times; subsequent calls have no effect.
+func (pr *PartitionReader) Close() {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if pr.closed {
+		return
+	}
+
+	pr.closed = true
+	if err := pr.consumer.Close(); err != nil {
+		pr.logger.Errorf("Error closing Kafka consumer: %v", err)
+	}
+	if err := pr.client.Close(); err != nil {
+		pr.logger.Errorf("Error closing Kafka client: %v", err)
+	}
+}
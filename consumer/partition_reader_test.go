@@ -0,0 +1,94 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/dawit-go/notification-kafka-lib/config"
+)
+
+// gapClient is a sarama.Client reporting a fixed high-water mark and oldest offset.
+type gapClient struct {
+	sarama.Client
+	config *sarama.Config
+	hwm    int64
+}
+
+func (c *gapClient) Config() *sarama.Config {
+	return c.config
+}
+
+func (c *gapClient) GetOffset(_ string, _ int32, offset int64) (int64, error) {
+	if offset == sarama.OffsetOldest {
+		return 0, nil
+	}
+	return c.hwm, nil
+}
+
+// gapConsumer is a sarama.Consumer whose partition consumers report a fixed
+// high-water mark, as a broker does once the consumer has fetched past a gap.
+type gapConsumer struct {
+	*mocks.Consumer
+	hwm int64
+}
+
+func (c *gapConsumer) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
+	pc, err := c.Consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &gapPartitionConsumer{PartitionConsumer: pc, hwm: c.hwm}, nil
+}
+
+type gapPartitionConsumer struct {
+	sarama.PartitionConsumer
+	hwm int64
+}
+
+func (pc *gapPartitionConsumer) HighWaterMarkOffset() int64 {
+	return pc.hwm
+}
+
+func TestReadStopsAtOffsetGapBeforeHighWaterMark(t *testing.T) {
+	kafkaConfig := mocks.NewTestConfig()
+	kafkaConfig.Consumer.MaxWaitTime = 10 * time.Millisecond
+
+	// Offsets 3 and 4 hold no messages, e.g. transaction markers, so only offsets 0-2
+	// are ever delivered although the high-water mark is 5.
+	consumer := mocks.NewConsumer(t, kafkaConfig)
+	pc := consumer.ExpectConsumePartition("replay", 0, 0)
+	for range 3 {
+		pc.YieldMessage(&sarama.ConsumerMessage{Value: []byte("{}")})
+	}
+
+	pr := &PartitionReader{
+		client:   &gapClient{config: kafkaConfig, hwm: 5},
+		consumer: &gapConsumer{Consumer: consumer, hwm: 5},
+		logger:   loggerOrNop(nil),
+		config:   config.KafkaConfig{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var offsets []int64
+	count, err := pr.Read(ctx, ReplayRange{Topic: "replay", Partition: 0, StartOffset: sarama.OffsetOldest}, func(_ context.Context, msg *sarama.ConsumerMessage) error {
+		offsets = append(offsets, msg.Offset)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read returned error %v, want nil", err)
+	}
+	if count != 3 || len(offsets) != 3 || offsets[2] != 2 {
+		t.Fatalf("Read replayed %d messages at offsets %v, want 3 at offsets [0 1 2]", count, offsets)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Read waited until the context expired instead of stopping at the gap")
+	}
+	if err := consumer.Close(); err != nil {
+		t.Fatalf("closing mock consumer: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package consumer
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// defaultFailureCapacity is the number of message IDs tracked by the in-memory
+// FailureTracker created when WithFailureTracker is given a nil tracker.
+const defaultFailureCapacity = 10000
+
+// FailureTracker counts the failed processing attempts of each message ID, so that a
+// message that keeps failing can be recognized as poison even when every attempt
+// happens in a new consumer group session. Implementations must be safe for
+// concurrent use, and should persist counts outside the process to survive restarts.
+type FailureTracker interface {
+	// Increment increments the failure count of id and returns the new count.
+	Increment(ctx context.Context, id string) (int, error)
+	// Count returns the failure count of id, or zero if it has none.
+	Count(ctx context.Context, id string) (int, error)
+	// Reset clears the failure count of id.
+	Reset(ctx context.Context, id string) error
+}
+
+// MemoryFailureTracker is an in-memory FailureTracker that keeps the counts of the
+// most recently failed IDs, evicting the least recently failed one once capacity is
+// reached. Counts survive rebalances but not restarts of the process.
+type MemoryFailureTracker struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Front is the most recently incremented ID
+	mu       sync.Mutex
+}
+
+// failureEntry is the value stored in MemoryFailureTracker.order.
+type failureEntry struct {
+	id    string
+	count int
+}
+
+// NewMemoryFailureTracker creates a MemoryFailureTracker that tracks up to capacity
+// IDs. A capacity of zero or less uses a default of 10000.
+func NewMemoryFailureTracker(capacity int) *MemoryFailureTracker {
+	if capacity <= 0 {
+		capacity = defaultFailureCapacity
+	}
+	return &MemoryFailureTracker{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Increment increments the failure count of id, evicting the least recently
+// incremented ID if the tracker is full.
+func (t *MemoryFailureTracker) Increment(_ context.Context, id string) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.entries[id]; ok {
+		entry := elem.Value.(*failureEntry)
+		entry.count++
+		t.order.MoveToFront(elem)
+		return entry.count, nil
+	}
+
+	t.entries[id] = t.order.PushFront(&failureEntry{id: id, count: 1})
+	if t.order.Len() > t.capacity {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(*failureEntry).id)
+	}
+	return 1, nil
+}
+
+// Count returns the failure count of id, or zero if it is not tracked.
+func (t *MemoryFailureTracker) Count(_ context.Context, id string) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.entries[id]; ok {
+		return elem.Value.(*failureEntry).count, nil
+	}
+	return 0, nil
+}
+
+// Reset removes the failure count of id.
+func (t *MemoryFailureTracker) Reset(_ context.Context, id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.entries[id]; ok {
+		t.order.Remove(elem)
+		delete(t.entries, id)
+	}
+	return nil
+}
+
+// poisoned reports whether msg has already failed as many times as the
+// WithFailureTracker limit allows, checked before its handler runs so that a known
+// poison message does not use up the handler or its retries again. A poison message is
+// dead-lettered if a dead-letter topic is configured and its count is cleared, and the
+// caller skips it. Tracker errors are logged and msg is processed.
+func (nc *NotificationConsumer) poisoned(ctx context.Context, msg *sarama.ConsumerMessage, notificationMsg *dto.NotificationMessage) bool {
+	if nc.failures == nil {
+		return false
+	}
+
+	failures, err := nc.failures.Count(ctx, notificationMsg.ID)
+	if err != nil {
+		nc.logger.Errorf("Failure lookup failed, processing anyway | ID: %s | Error: %v", notificationMsg.ID, err)
+		return false
+	}
+	if failures < nc.maxFailures {
+		return false
+	}
+
+	nc.logger.Errorf("Skipping poison message | ID: %s | Type: %s | Topic: %s | Failures: %d", notificationMsg.ID, notificationMsg.Type, msg.Topic, failures)
+	if nc.dlq != nil {
+		nc.deadLetter(msg, ReasonPoisonMessage, fmt.Errorf("message failed %d times", failures))
+	}
+	nc.resetFailures(ctx, notificationMsg.ID)
+	return true
+}
+
+// recordFailure counts a failed attempt to handle the message id, so that poisoned
+// recognizes it on redelivery.
+func (nc *NotificationConsumer) recordFailure(ctx context.Context, id string) {
+	if nc.failures == nil {
+		return
+	}
+	if _, err := nc.failures.Increment(ctx, id); err != nil {
+		nc.logger.Errorf("Failed to record failure | ID: %s | Error: %v", id, err)
+	}
+}
+
+// resetFailures clears the failure count of the message id once it has been processed
+// successfully.
+func (nc *NotificationConsumer) resetFailures(ctx context.Context, id string) {
+	if nc.failures == nil {
+		return
+	}
+	if err := nc.failures.Reset(ctx, id); err != nil {
+		nc.logger.Errorf("Failed to reset failure count | ID: %s | Error: %v", id, err)
+	}
+}
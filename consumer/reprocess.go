@@ -0,0 +1,118 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// ReprocessDLQ republishes dead-lettered messages for normal processing, typically
+// after the bug that made their handler fail has been fixed. It reads every partition
+// of the dead-letter topic from the oldest retained message up to the high-water mark
+// at the time of the call, and republishes each message accepted by filter (all
+// messages if filter is nil) with its dlq_* headers stripped and its value, key, and
+// other headers unchanged.
+//
+// Messages are republished to targetTopic, with the configured TopicPrefix prepended,
+// or to their original topic from the dlq_source_topic header if targetTopic is empty.
+// Messages that cannot be decoded, or that have no source topic when targetTopic is
+// empty, are logged and skipped. Kafka cannot delete from the dead-letter topic, so
+// every call scans it in full; use filter, e.g. by ID or CreatedAt, to avoid
+// republishing a message twice. For large dead-letter topics, DrainDLQ republishes
+// concurrently and resumes where it left off.
+//
+// Returns the number of messages republished, and the error that stopped
+// reprocessing, if any. Requires WithDeadLetterTopic.
+func (nc *NotificationConsumer) ReprocessDLQ(ctx context.Context, targetTopic string, filter func(dto.NotificationMessage) bool) (int, error) {
+	if nc.dlq == nil {
+		return 0, fmt.Errorf("dead-letter topic not configured")
+	}
+	if targetTopic != "" {
+		targetTopic = nc.config.Topic(targetTopic)
+	}
+
+	reader, err := NewPartitionReader(nc.config, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	partitions, err := reader.client.Partitions(nc.dlqTopic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list partitions for %s: %w", nc.dlqTopic, err)
+	}
+
+	count := 0
+	republish := func(_ context.Context, msg *sarama.ConsumerMessage) error {
+		outcome, err := nc.reprocessMessage(msg, targetTopic, filter)
+		if outcome == reprocessRepublished {
+			count++
+		}
+		return err
+	}
+
+	for _, partition := range partitions {
+		rng := ReplayRange{Topic: nc.dlqTopic, Partition: partition, StartOffset: sarama.OffsetOldest}
+		if _, err := reader.read(ctx, rng, republish); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// reprocessOutcome is what happened to a dead-lettered message during reprocessing.
+type reprocessOutcome int
+
+const (
+	reprocessRepublished reprocessOutcome = iota // Republished
+	reprocessFiltered                            // Rejected by the filter
+	reprocessSkipped                             // Undecodable, or without a topic to republish to
+	reprocessFailed                              // Republishing failed
+)
+
+// reprocessMessage republishes the dead-lettered msg to targetTopic, which must
+// already carry the TopicPrefix, or to its source topic if targetTopic is empty,
+// unless filter rejects it. Messages that cannot be decoded or have no topic to
+// republish to are logged and skipped.
+//
+// Returns what happened to msg, and an error if republishing failed.
+func (nc *NotificationConsumer) reprocessMessage(msg *sarama.ConsumerMessage, targetTopic string, filter func(dto.NotificationMessage) bool) (reprocessOutcome, error) {
+	var notificationMsg dto.NotificationMessage
+	if err := decodeMessage(msg, &notificationMsg, nc.payloadCipher); err != nil {
+		nc.logger.Errorf("Skipping undecodable dead-lettered message | Partition: %d | Offset: %d | Error: %v", msg.Partition, msg.Offset, err)
+		return reprocessSkipped, nil
+	}
+	if filter != nil && !filter(notificationMsg) {
+		return reprocessFiltered, nil
+	}
+
+	topic := targetTopic
+	if topic == "" {
+		if topic, _ = headerValue(msg.Headers, HeaderDLQSourceTopic); topic == "" {
+			nc.logger.Errorf("Skipping dead-lettered message without source topic | ID: %s | Offset: %d", notificationMsg.ID, msg.Offset)
+			return reprocessSkipped, nil
+		}
+	}
+
+	if _, _, err := nc.dlq.SendMessage(reprocessedMessage(msg, topic)); err != nil {
+		return reprocessFailed, fmt.Errorf("failed to republish message %s to %s: %w", notificationMsg.ID, topic, err)
+	}
+	nc.logger.Infof("Dead-lettered message republished | ID: %s | Topic: %s | DLQ Offset: %d", notificationMsg.ID, topic, msg.Offset)
+	return reprocessRepublished, nil
+}
+
+// reprocessedMessage returns a copy of the dead-lettered msg for topic, without its
+// dlq_* headers.
+func reprocessedMessage(msg *sarama.ConsumerMessage, topic string) *sarama.ProducerMessage {
+	kafkaMsg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: withoutDLQHeaders(msg.Headers),
+	}
+	if msg.Key != nil {
+		kafkaMsg.Key = sarama.ByteEncoder(msg.Key)
+	}
+	return kafkaMsg
+}
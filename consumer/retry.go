@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// retryMaxBackoff caps the backoff of the Retry middleware.
+const retryMaxBackoff = 30 * time.Second
+
+// RetryAfterError is a handler error carrying how long to wait before the next
+// attempt, such as the Retry-After of a provider's 429 response. Retry and
+// SinkHandler wait Delay instead of their own backoff before retrying it.
+type RetryAfterError struct {
+	Delay time.Duration
+}
+
+// RetryAfter returns a RetryAfterError asking for the next attempt in d. Wrap it to
+// keep the underlying cause, e.g. fmt.Errorf("mailjet rate limited: %w",
+// consumer.RetryAfter(d)).
+func RetryAfter(d time.Duration) error {
+	return &RetryAfterError{Delay: d}
+}
+
+// Error implements error.
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry after %s", e.Delay)
+}
+
+// retryDelay returns the delay requested by a RetryAfterError in err's chain, or
+// backoff if there is none.
+func retryDelay(err error, backoff time.Duration) time.Duration {
+	var retryAfter *RetryAfterError
+	if errors.As(err, &retryAfter) && retryAfter.Delay >= 0 {
+		return retryAfter.Delay
+	}
+	return backoff
+}
+
+// Retry returns a middleware that retries a failed handler up to attempts times in
+// total (at least once), waiting backoff before the first retry and doubling it
+// after each, up to 30 seconds. When the handler fails with a RetryAfterError, that
+// attempt waits the requested delay instead, so handlers cooperate with downstream
+// rate limits. Retries stop when ctx is done, such as on a rebalance.
+//
+// The retries block the partition, so keep attempts and delays well within the
+// session timeout. The last error is returned, and dead-lettered if a dead-letter
+// topic is configured.
+func Retry(attempts int, backoff time.Duration) Middleware {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *dto.NotificationMessage) error {
+			wait := backoff
+			for attempt := 1; ; attempt++ {
+				err := next(ctx, msg)
+				if err == nil || attempt >= attempts {
+					return err
+				}
+
+				select {
+				case <-time.After(retryDelay(err, wait)):
+				case <-ctx.Done():
+					return err
+				}
+				wait = min(wait*2, retryMaxBackoff)
+			}
+		}
+	}
+}
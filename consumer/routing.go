@@ -0,0 +1,60 @@
+package consumer
+
+import (
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// HeaderChannelOverride is the conventional header for use with HeaderRouter, naming
+// the handler to use instead of the one for the message's Type.
+const HeaderChannelOverride = "channel_override"
+
+// RouteFunc selects the handler for a decoded message from the message and its raw
+// Kafka headers. It returns the key the handler was registered under with Handle,
+// or "" to fall back to routing by the message's Type.
+type RouteFunc func(msg *dto.NotificationMessage, headers map[string]string) string
+
+// HeaderRouter returns a RouteFunc that routes by the value of header, falling back to
+// the message's Type when the header is absent. For example, with
+// HeaderRouter(HeaderChannelOverride), an "email" message carrying the header
+// channel_override: sms_fallback is handled by the handler registered for
+// "sms_fallback".
+func HeaderRouter(header string) RouteFunc {
+	return func(_ *dto.NotificationMessage, headers map[string]string) string {
+		return headers[header]
+	}
+}
+
+// WithRouter selects handlers with fn instead of by the message's Type. Messages are
+// decoded before routing, so the type header fast path that skips decoding messages
+// without a handler is not used. Handlers registered with HandleTopic remain the
+// fallback when no handler is registered for the key fn returns.
+func WithRouter(fn RouteFunc) Option {
+	return func(nc *NotificationConsumer) {
+		nc.router = fn
+	}
+}
+
+// route returns the handler key for msg: the key chosen by the router, if any, or
+// else its Type.
+func (nc *NotificationConsumer) route(msg *dto.NotificationMessage, headers []*sarama.RecordHeader) string {
+	if nc.router == nil {
+		return msg.Type
+	}
+	if key := nc.router(msg, headerMap(headers)); key != "" {
+		return key
+	}
+	return msg.Type
+}
+
+// headerMap returns headers as a map keyed by header key. When a key repeats, the
+// last value wins.
+func headerMap(headers []*sarama.RecordHeader) map[string]string {
+	m := make(map[string]string, len(headers))
+	for _, header := range headers {
+		if header != nil {
+			m[string(header.Key)] = string(header.Value)
+		}
+	}
+	return m
+}
@@ -0,0 +1,73 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// Backoff between attempts of a failed Sink.Store, doubling from the initial value.
+const (
+	sinkInitialBackoff = 200 * time.Millisecond
+	sinkMaxBackoff     = 5 * time.Second
+)
+
+// Sink persists in-app notifications, e.g. to a database table or an outbox, which is
+// how the in-app channel is delivered. Implementations must be safe for concurrent
+// use, and should be idempotent because messages may be redelivered.
+type Sink interface {
+	// Store persists msg.
+	Store(ctx context.Context, msg dto.InAppKafkaMessage) error
+}
+
+// NopSink is a Sink that discards every message.
+type NopSink struct{}
+
+// Store implements Sink.
+func (NopSink) Store(context.Context, dto.InAppKafkaMessage) error {
+	return nil
+}
+
+// StoreInApp registers a handler for "in_app" messages that stores each one via sink.
+// A failed Store is retried with exponential backoff up to attempts times in total; if
+// it still fails, the handler fails and the message is dead-lettered when a
+// dead-letter topic is configured.
+func (nc *NotificationConsumer) StoreInApp(sink Sink, attempts int) {
+	nc.Handle("in_app", SinkHandler(sink, attempts))
+}
+
+// SinkHandler returns a Handler that decodes InAppKafkaMessage payloads and stores them
+// via sink, trying Store up to attempts times (at least once) with exponential backoff
+// between attempts. A Store that fails with a RetryAfterError waits the requested
+// delay instead of the backoff. Use it with HandleTopic to store messages of another type or topic.
+func SinkHandler(sink Sink, attempts int) Handler {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(ctx context.Context, msg *dto.NotificationMessage) error {
+		var inApp dto.InAppKafkaMessage
+		if err := msg.UnmarshalPayload(&inApp); err != nil {
+			return fmt.Errorf("failed to decode in-app message: %w", err)
+		}
+
+		backoff := sinkInitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := sink.Store(ctx, inApp)
+			if err == nil {
+				return nil
+			}
+			if attempt >= attempts {
+				return fmt.Errorf("failed to store in-app message after %d attempts: %w", attempt, err)
+			}
+
+			select {
+			case <-time.After(retryDelay(err, backoff)):
+			case <-ctx.Done():
+				return fmt.Errorf("failed to store in-app message: %w", err)
+			}
+			backoff = min(backoff*2, sinkMaxBackoff)
+		}
+	}
+}
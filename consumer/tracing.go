@@ -0,0 +1,59 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer starts an OpenTelemetry span with tracer around every handler
+// invocation, named "<topic>/<type> process" and carrying the message ID, topic,
+// partition, and offset as attributes. A handler error is recorded on the span and
+// sets its status. The trace context that the producer propagated in the message
+// headers, using the global propagator (see otel.SetTextMapPropagator), becomes the
+// span's parent, so a notification is traced from the publishing request through
+// its handling. The span's context is passed to the handler.
+func WithTracer(tracer trace.Tracer) Option {
+	return func(nc *NotificationConsumer) {
+		nc.tracer = tracer
+	}
+}
+
+// startSpan starts the handler span for notificationMsg, read from msg, as a child of
+// the trace context propagated in msg's headers, if WithTracer is set.
+//
+// Returns the context carrying the span and the span, which is a no-op span without
+// WithTracer.
+func (nc *NotificationConsumer) startSpan(ctx context.Context, msg *sarama.ConsumerMessage, notificationMsg *dto.NotificationMessage) (context.Context, trace.Span) {
+	if nc.tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headerMap(msg.Headers)))
+	return nc.tracer.Start(ctx, fmt.Sprintf("%s/%s process", msg.Topic, notificationMsg.Type),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.message.id", notificationMsg.ID),
+			attribute.String("notification.type", notificationMsg.Type),
+			attribute.Int("messaging.destination.partition.id", int(msg.Partition)),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		))
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,257 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+)
+
+// StatusEvent is a message produced by a TransactionalHandler in the same transaction
+// as the offset of the message it was produced for.
+type StatusEvent struct {
+	Topic   string      // Topic to produce to; the configured TopicPrefix is prepended
+	Type    string      // Type of the produced NotificationMessage, e.g. "otp_status"
+	Payload interface{} // Payload of the produced NotificationMessage
+}
+
+// TransactionalHandler processes a single notification message and returns the status
+// events to produce for it. Returning an error or panicking aborts the transaction.
+type TransactionalHandler func(ctx context.Context, msg *dto.NotificationMessage) ([]StatusEvent, error)
+
+// ProcessTransactionally consumes the subscribed topics with exactly-once semantics:
+// for every message, the status events returned by handler and the message's offset
+// are committed to Kafka in a single transaction (read-process-write), so a message is
+// either fully processed or not processed at all. Use it where duplicate side effects
+// are unacceptable, such as OTP delivery.
+//
+// transactionalID must be stable across restarts of the same instance and unique across
+// instances, so that the broker can fence zombie producers. Registered handlers are not
+// used, and ProcessTransactionally must not run concurrently with Start. Messages that
+// cannot be decoded, whose expires_at header has passed, that are older than
+// DropIfOlderThan allows, that are poison (see WithFailureTracker), or that were
+// published by SelfTest are skipped by committing their offset with no status events.
+//
+// Processing stops at the first handler or transaction error; the transaction is aborted
+// and the message is redelivered the next time the group consumes the partition.
+// Handlers should therefore return an error only for failures worth retrying.
+//
+// Returns nil when ctx is cancelled or the consumer is closed, or the error that
+// stopped processing.
+func (nc *NotificationConsumer) ProcessTransactionally(ctx context.Context, transactionalID string, handler TransactionalHandler) error {
+	if transactionalID == "" {
+		return fmt.Errorf("transactional ID is required")
+	}
+	if nc.assigned != nil {
+		return fmt.Errorf("transactional processing requires a consumer group, not assigned partitions")
+	}
+
+	brokers, err := kafkautil.Brokers(nc.config)
+	if err != nil {
+		return err
+	}
+
+	kafkaConfig := kafkautil.NewSaramaConfig(nc.config)
+	kafkaConfig.Producer.Idempotent = true
+	kafkaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	kafkaConfig.Producer.Retry.Max = 3
+	kafkaConfig.Producer.Return.Successes = true
+	kafkaConfig.Producer.Transaction.ID = transactionalID
+	kafkaConfig.Net.MaxOpenRequests = 1
+
+	txnProducer, err := sarama.NewSyncProducer(brokers, kafkaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create transactional Kafka producer: %w", err)
+	}
+	defer func() {
+		if err := txnProducer.Close(); err != nil {
+			nc.logger.Errorf("Error closing transactional Kafka producer: %v", err)
+		}
+	}()
+
+	txnCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	err = nc.consume(txnCtx, &txnGroupHandler{
+		nc:       nc,
+		producer: txnProducer,
+		handler:  handler,
+		stop:     stop,
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.Err() == nil {
+		if cause := context.Cause(txnCtx); cause != nil {
+			return cause
+		}
+	}
+	return nil
+}
+
+// txnGroupHandler adapts a TransactionalHandler to sarama.ConsumerGroupHandler.
+type txnGroupHandler struct {
+	nc       *NotificationConsumer
+	producer sarama.SyncProducer
+	handler  TransactionalHandler
+	stop     context.CancelCauseFunc // Stops ProcessTransactionally with the given error
+	txnMu    sync.Mutex              // Serializes transactions across partition claims
+}
+
+// Setup is run at the beginning of a new session, before ConsumeClaim.
+func (h *txnGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	h.nc.applyTopicOffsetReset(session)
+	h.nc.markReady(session)
+	return nil
+}
+
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited.
+func (h *txnGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim processes the messages of a single partition claim in order, one
+// transaction per message. The transactional producer supports a single open
+// transaction, so claims are processed one message at a time across partitions.
+func (h *txnGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if err := h.process(session.Context(), msg); err != nil {
+				h.nc.logger.Errorf("Transactional processing stopped | Topic: %s | Partition: %d | Offset: %d | Error: %v", msg.Topic, msg.Partition, msg.Offset, err)
+				h.stop(err)
+				return nil
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// process runs the handler for msg and commits its status events together with its
+// offset. The transaction is aborted on any error.
+func (h *txnGroupHandler) process(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	h.txnMu.Lock()
+	defer h.txnMu.Unlock()
+
+	if err := h.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var events []StatusEvent
+	var notificationMsg dto.NotificationMessage
+	succeeded := false
+	if msg.Value == nil {
+		h.nc.logger.Infof("Skipping tombstone | Topic: %s | Offset: %d", msg.Topic, msg.Offset)
+	} else if expiresAt, expired := expiredAt(msg); expired {
+		h.nc.logger.Infof("Skipping expired message | Topic: %s | Offset: %d | Expired: %s", msg.Topic, msg.Offset, expiresAt.Format(time.RFC3339))
+	} else if age, stale := h.nc.staleAge(msg); stale {
+		h.nc.logger.Infof("Skipping stale message | Topic: %s | Offset: %d | Age: %s", msg.Topic, msg.Offset, age.Round(time.Second))
+		h.nc.recordStale(msg)
+	} else if isSelfTest(msg) {
+		h.nc.logger.Infof("Skipping self-test message | Topic: %s | Offset: %d", msg.Topic, msg.Offset)
+	} else if err := decodeMessage(msg, &notificationMsg, h.nc.payloadCipher); err != nil {
+		h.nc.logger.Errorf("Failed to decode message | Topic: %s | Partition: %d | Offset: %d | Error: %v", msg.Topic, msg.Partition, msg.Offset, err)
+	} else if h.nc.poisoned(ctx, msg, &notificationMsg) {
+		// Skipped: committed below with no status events.
+	} else {
+		ctx = withMessageInfo(ctx, MessageInfo{
+			Topic:         msg.Topic,
+			Partition:     msg.Partition,
+			Offset:        msg.Offset,
+			Timestamp:     msg.Timestamp,
+			SchemaVersion: schemaVersion(msg),
+		})
+		ctx, span := h.nc.startSpan(ctx, msg, &notificationMsg)
+		events, err = h.invoke(ctx, &notificationMsg)
+		endSpan(span, err)
+		if err != nil {
+			h.nc.recordFailure(ctx, notificationMsg.ID)
+			return h.abort(fmt.Errorf("handler failed for message %s: %w", notificationMsg.ID, err))
+		}
+		succeeded = true
+	}
+
+	if len(events) > 0 {
+		kafkaMsgs, err := h.nc.statusMessages(events)
+		if err != nil {
+			return h.abort(err)
+		}
+		if err := h.producer.SendMessages(kafkaMsgs); err != nil {
+			return h.abort(fmt.Errorf("failed to produce status events: %w", err))
+		}
+	}
+
+	if err := h.producer.AddMessageToTxn(msg, h.nc.config.ConsumerGroup, nil); err != nil {
+		return h.abort(fmt.Errorf("failed to add offset to transaction: %w", err))
+	}
+	if err := h.producer.CommitTxn(); err != nil {
+		return h.abort(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	if succeeded {
+		h.nc.resetFailures(ctx, notificationMsg.ID)
+	}
+	return nil
+}
+
+// invoke runs the handler for msg, turning a panic into an error wrapping
+// ErrHandlerPanic, and records its duration.
+func (h *txnGroupHandler) invoke(ctx context.Context, msg *dto.NotificationMessage) (events []StatusEvent, err error) {
+	defer h.nc.recordHandle(msg, time.Now(), &err)
+	defer recoverPanic(h.nc.logger, msg, &err)
+	return h.handler(ctx, msg)
+}
+
+// abort aborts the open transaction unless the producer is in a fatal state, and
+// returns err together with any abort error.
+func (h *txnGroupHandler) abort(err error) error {
+	if h.producer.TxnStatus()&sarama.ProducerTxnFlagFatalError != 0 {
+		return err
+	}
+	if abortErr := h.producer.AbortTxn(); abortErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to abort transaction: %w", abortErr))
+	}
+	return err
+}
+
+// statusMessages builds the Kafka messages for events, wrapping each payload in a
+// NotificationMessage with the same headers the producer sets.
+func (nc *NotificationConsumer) statusMessages(events []StatusEvent) ([]*sarama.ProducerMessage, error) {
+	kafkaMsgs := make([]*sarama.ProducerMessage, 0, len(events))
+	for _, event := range events {
+		notificationMsg, err := dto.NewNotificationMessage(
+			fmt.Sprintf("%s-%d", event.Type, time.Now().UnixNano()),
+			event.Type,
+			event.Payload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s status event: %w", event.Type, err)
+		}
+
+		messageBytes, err := json.Marshal(notificationMsg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s status event: %w", event.Type, err)
+		}
+
+		kafkaMsgs = append(kafkaMsgs, &sarama.ProducerMessage{
+			Topic:     nc.config.Topic(event.Topic),
+			Value:     sarama.ByteEncoder(messageBytes),
+			Timestamp: notificationMsg.CreatedAt,
+			Headers: []sarama.RecordHeader{
+				{Key: []byte("message_id"), Value: []byte(notificationMsg.ID)},
+				{Key: []byte("type"), Value: []byte(notificationMsg.Type)},
+				{Key: []byte("timestamp"), Value: []byte(notificationMsg.CreatedAt.Format(time.RFC3339))},
+			},
+		})
+	}
+	return kafkaMsgs, nil
+}
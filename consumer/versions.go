@@ -0,0 +1,96 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/producer"
+)
+
+// ErrNoDecoder is returned by DecoderRegistry.Decode when no decoder is registered for
+// a message's type and schema version.
+var ErrNoDecoder = errors.New("no decoder registered")
+
+// PayloadDecoder decodes the payload of a message of one schema version into the
+// value a handler works with.
+type PayloadDecoder func(payload json.RawMessage) (interface{}, error)
+
+// JSONDecoder returns a PayloadDecoder that unmarshals payloads into a T, such as a
+// struct describing one version of a payload.
+func JSONDecoder[T any]() PayloadDecoder {
+	return func(payload json.RawMessage) (interface{}, error) {
+		var v T
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// DecoderRegistry maps message types and payload schema versions to PayloadDecoders,
+// so that a handler can decode messages published before and after a DTO change
+// while producers and consumers are rolled out. It is safe for concurrent use.
+type DecoderRegistry struct {
+	mu       sync.RWMutex
+	decoders map[decoderKey]PayloadDecoder
+}
+
+// decoderKey identifies the decoder of one schema version of a message type.
+type decoderKey struct {
+	msgType string
+	version int
+}
+
+// NewDecoderRegistry creates an empty DecoderRegistry.
+func NewDecoderRegistry() *DecoderRegistry {
+	return &DecoderRegistry{decoders: make(map[decoderKey]PayloadDecoder)}
+}
+
+// Register sets decoder as the decoder of schema version of messages of msgType,
+// replacing any previously registered one.
+func (r *DecoderRegistry) Register(msgType string, version int, decoder PayloadDecoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[decoderKey{msgType: msgType, version: version}] = decoder
+}
+
+// Decode decodes the payload of msg with the decoder registered for its Type and the
+// SchemaVersion of the MessageInfo in ctx, which handlers receive from the consumer.
+// Outside a handler, version 1 is assumed.
+//
+// Returns an error wrapping ErrNoDecoder if no decoder is registered, or the error
+// returned by the decoder.
+func (r *DecoderRegistry) Decode(ctx context.Context, msg *dto.NotificationMessage) (interface{}, error) {
+	version := 1
+	if info, ok := MessageInfoFromContext(ctx); ok {
+		version = info.SchemaVersion
+	}
+
+	r.mu.RLock()
+	decoder, ok := r.decoders[decoderKey{msgType: msg.Type, version: version}]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w for %s messages of schema version %d", ErrNoDecoder, msg.Type, version)
+	}
+	return decoder(msg.Payload)
+}
+
+// schemaVersion returns the payload schema version from the schema_version header of
+// msg, 1 if the header is missing, or 0 if it is not a positive integer.
+func schemaVersion(msg *sarama.ConsumerMessage) int {
+	value, ok := headerValue(msg.Headers, producer.HeaderSchemaVersion)
+	if !ok {
+		return 1
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0
+	}
+	return version
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// MaxTransactionDetailsBytes is the largest marshaled size accepted for the
+// TransactionDetails of an email, keeping a single field from ballooning the message.
+// It defaults to 32 KB. Services with legitimately larger details can raise it once
+// at startup, before any message is validated; it must not be changed concurrently
+// with validation.
+var MaxTransactionDetailsBytes = 32 * 1024
+
+// validateTransactionDetails is an ozzo-validation rule for TransactionDetails. Each
+// entry must marshal to JSON, and together they must not exceed
+// MaxTransactionDetailsBytes.
+//
+// Returns an error naming the first key, in sorted order, whose value cannot be
+// marshaled, or the total size if it exceeds the limit.
+func validateTransactionDetails(value interface{}) error {
+	details, _ := value.(map[string]interface{})
+	if len(details) == 0 {
+		return nil
+	}
+
+	size := 2 // The enclosing braces
+	for _, key := range slices.Sorted(maps.Keys(details)) {
+		valueBytes, err := json.Marshal(details[key])
+		if err != nil {
+			var typeErr *json.UnsupportedTypeError
+			if errors.As(err, &typeErr) {
+				return fmt.Errorf("key %q has unsupported type %s", key, typeErr.Type)
+			}
+			return fmt.Errorf("key %q cannot be serialized: %w", key, err)
+		}
+		size += len(key) + len(valueBytes) + 4 // Quotes, colon, and comma
+	}
+
+	if size > MaxTransactionDetailsBytes {
+		return fmt.Errorf("serialized size of about %d bytes exceeds limit of %d bytes", size, MaxTransactionDetailsBytes)
+	}
+	return nil
+}
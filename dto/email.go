@@ -1,7 +1,11 @@
 package dto
 
 import (
+	"strings"
+	"time"
+
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
 
 // EmailContact represents an email contact
@@ -10,6 +14,21 @@ type EmailContact struct {
 	Email string `json:"email" bson:"email"`
 }
 
+// Validate validates the EmailContact address
+func (c EmailContact) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Email, validation.Required.Error("email is required"), is.EmailFormat),
+	)
+}
+
+// ValidateDetailed validates the EmailContact like Validate, reporting every invalid
+// field.
+//
+// Returns nil if the contact is valid.
+func (c EmailContact) ValidateDetailed() ValidationErrors {
+	return detailedErrors(c.Validate())
+}
+
 // SendEmailRequest represents the request to send an email
 type SendEmailRequest struct {
 	Recipients         []EmailContact         `json:"recipients" validate:"required"`
@@ -22,21 +41,50 @@ type SendEmailRequest struct {
 	CustomerName       string                 `json:"customer_name,omitempty"`
 	TransactionDetails map[string]interface{} `json:"transaction_details,omitempty"`
 	CC                 []EmailContact         `json:"cc,omitempty" bson:"cc,omitempty"`
+	BCC                []EmailContact         `json:"bcc,omitempty" bson:"bcc,omitempty"`
+	Locale             string                 `json:"locale,omitempty"` // BCP 47 language tag, e.g. "am" or "en"
+	Priority           int                    `json:"priority,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	SenderEmail        string                 `json:"sender_email,omitempty"` // Overrides the configured sender address
+	SenderName         string                 `json:"sender_name,omitempty"`  // Overrides the configured sender name
 }
 
 // Validate validates the SendEmailRequest fields
 func (s SendEmailRequest) Validate() error {
 	return validation.ValidateStruct(&s,
 		validation.Field(&s.Recipients, validation.Required.Error("recipients are required")),
+		validation.Field(&s.CC),
+		validation.Field(&s.BCC),
 		validation.Field(&s.Subject, validation.Required.Error("subject is required")),
 		validation.Field(&s.Type, validation.Required.Error("type is required")),
+		validation.Field(&s.Locale, validation.By(validateLocale)),
+		validation.Field(&s.TransactionDetails, validation.By(validateTransactionDetails)),
+		validation.Field(&s.SenderEmail, is.EmailFormat),
 	)
 }
 
+// ValidateDetailed validates the SendEmailRequest like Validate, reporting every
+// invalid field, including those of individual recipients, such as
+// "recipients.0.email".
+//
+// Returns nil if the request is valid.
+func (s SendEmailRequest) ValidateDetailed() ValidationErrors {
+	return detailedErrors(s.Validate())
+}
+
+// Email types understood by the email service, used as the Type of SendEmailRequest
+// and EmailKafkaMessage.
+const (
+	EmailTypeOTP         = "otp"
+	EmailTypeTransaction = "transaction"
+	EmailTypeMessage     = "message"
+)
+
 // EmailKafkaMessage represents an email message consumed from Kafka
 type EmailKafkaMessage struct {
 	Recipients         []EmailContact         `json:"recipients"`
 	CC                 []EmailContact         `json:"cc,omitempty"`
+	BCC                []EmailContact         `json:"bcc,omitempty"`
 	Subject            string                 `json:"subject"`
 	Type               string                 `json:"type"` // "otp", "message", "transaction", etc.
 	OTPCode            string                 `json:"otp_code,omitempty"`
@@ -46,21 +94,208 @@ type EmailKafkaMessage struct {
 	CustomerName       string                 `json:"customer_name,omitempty"`
 	TransactionDetails map[string]interface{} `json:"transaction_details,omitempty"`
 	Priority           int                    `json:"priority,omitempty"`
-	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	// MessageBodyEncoding is MessageBodyEncodingGzip when MessageBody is compressed,
+	// or empty for a plain body. See DecodedMessageBody.
+	MessageBodyEncoding string                 `json:"message_body_encoding,omitempty"`
+	Metadata            map[string]interface{} `json:"metadata,omitempty"`
+	Locale              string                 `json:"locale,omitempty"` // BCP 47 language tag, e.g. "am" or "en"
+	ExpiresAt           *time.Time             `json:"expires_at,omitempty"`
+	// SenderEmail and SenderName override the email service's configured sender for
+	// this message, e.g. "security@" for OTPs; empty values keep the default.
+	SenderEmail string `json:"sender_email,omitempty"`
+	SenderName  string `json:"sender_name,omitempty"`
+}
+
+// Validate validates that the EmailKafkaMessage has recipients and the fields required
+// by its Type, so that an email cannot go out blank: "otp" requires OTPCode,
+// "transaction" requires TransactionDetails, and "message" requires MessageBody.
+// TransactionDetails must also serialize cleanly, which would otherwise fail late,
+// when the message is marshaled for publishing. Every Recipients, CC, and BCC address
+// and a SenderEmail override must be valid.
+func (e EmailKafkaMessage) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Recipients, validation.Required.Error("recipients are required")),
+		validation.Field(&e.CC),
+		validation.Field(&e.BCC),
+		validation.Field(&e.OTPCode,
+			validation.When(e.Type == EmailTypeOTP, validation.Required.Error("OTP code is required for otp emails"))),
+		validation.Field(&e.TransactionDetails,
+			validation.When(e.Type == EmailTypeTransaction, validation.Required.Error("transaction details are required for transaction emails")),
+			validation.By(validateTransactionDetails)),
+		validation.Field(&e.MessageBody,
+			validation.When(e.Type == EmailTypeMessage, validation.Required.Error("message body is required for message emails"))),
+		validation.Field(&e.SenderEmail, is.EmailFormat),
+	)
+}
+
+// ValidateDetailed validates the EmailKafkaMessage like Validate, reporting every
+// invalid field.
+//
+// Returns nil if the message is valid.
+func (e EmailKafkaMessage) ValidateDetailed() ValidationErrors {
+	return detailedErrors(e.Validate())
+}
+
+// Dedup normalizes the addresses of e to trimmed lower case and removes duplicate
+// contacts across Recipients, CC, and BCC, so that no address receives the email
+// twice. An address keeps its first occurrence, with primary recipients first, then
+// CC, then BCC: one that is already a recipient is dropped from CC and BCC, and one
+// already in CC is dropped from BCC. A kept contact without a Name takes the Name of
+// a dropped duplicate. The contact slices are replaced, not modified in place.
+func (e *EmailKafkaMessage) Dedup() {
+	seen := make(map[string]*EmailContact)
+	e.Recipients = dedupContacts(e.Recipients, seen)
+	e.CC = dedupContacts(e.CC, seen)
+	e.BCC = dedupContacts(e.BCC, seen)
+}
+
+// dedupContacts returns contacts with normalized addresses, without those in seen,
+// and adds the kept contacts to seen. Contacts with an empty address are kept as is,
+// for Validate to report.
+func dedupContacts(contacts []EmailContact, seen map[string]*EmailContact) []EmailContact {
+	if contacts == nil {
+		return nil
+	}
+	deduped := make([]EmailContact, 0, len(contacts))
+	positions := make(map[string]int, len(contacts))
+	for _, contact := range contacts {
+		contact.Email = strings.ToLower(strings.TrimSpace(contact.Email))
+		if contact.Email == "" {
+			deduped = append(deduped, contact)
+			continue
+		}
+		if kept, ok := seen[contact.Email]; ok {
+			if kept.Name == "" {
+				kept.Name = contact.Name
+			}
+			continue
+		}
+		if i, ok := positions[contact.Email]; ok {
+			if deduped[i].Name == "" {
+				deduped[i].Name = contact.Name
+			}
+			continue
+		}
+		positions[contact.Email] = len(deduped)
+		deduped = append(deduped, contact)
+	}
+	for email, i := range positions {
+		seen[email] = &deduped[i]
+	}
+	return deduped
 }
 
-// ToSendEmailRequest converts EmailKafkaMessage to SendEmailRequest
+// MessageLocale returns the locale of the email message.
+func (e EmailKafkaMessage) MessageLocale() string {
+	return e.Locale
+}
+
+// MessageExpiry returns the expiry of the email message, or nil if it does not expire.
+func (e EmailKafkaMessage) MessageExpiry() *time.Time {
+	return e.ExpiresAt
+}
+
+// ToSendEmailRequest converts EmailKafkaMessage to SendEmailRequest, decompressing a
+// compressed MessageBody. If the body cannot be decompressed it is copied unchanged;
+// call DecodedMessageBody first to handle that case as an error.
 func (e *EmailKafkaMessage) ToSendEmailRequest() SendEmailRequest {
+	body, err := e.DecodedMessageBody()
+	if err != nil {
+		body = e.MessageBody
+	}
+
 	return SendEmailRequest{
 		Recipients:         e.Recipients,
 		CC:                 e.CC,
+		BCC:                e.BCC,
 		Subject:            e.Subject,
 		Type:               e.Type,
 		OTPCode:            e.OTPCode,
 		Receiver:           e.Receiver,
-		MessageBody:        e.MessageBody,
+		MessageBody:        body,
 		Link:               e.Link,
 		CustomerName:       e.CustomerName,
 		TransactionDetails: e.TransactionDetails,
+		Locale:             e.Locale,
+		Priority:           e.Priority,
+		Metadata:           e.Metadata,
+		SenderEmail:        e.SenderEmail,
+		SenderName:         e.SenderName,
+	}
+}
+
+// ToKafkaMessage converts SendEmailRequest to EmailKafkaMessage
+func (s *SendEmailRequest) ToKafkaMessage() EmailKafkaMessage {
+	return EmailKafkaMessage{
+		Recipients:         s.Recipients,
+		CC:                 s.CC,
+		BCC:                s.BCC,
+		Subject:            s.Subject,
+		Type:               s.Type,
+		OTPCode:            s.OTPCode,
+		Receiver:           s.Receiver,
+		MessageBody:        s.MessageBody,
+		Link:               s.Link,
+		CustomerName:       s.CustomerName,
+		TransactionDetails: s.TransactionDetails,
+		Locale:             s.Locale,
+		Priority:           s.Priority,
+		Metadata:           s.Metadata,
+		SenderEmail:        s.SenderEmail,
+		SenderName:         s.SenderName,
+	}
+}
+
+// NewOTPEmail creates an OTP EmailKafkaMessage delivering otp to recipients.
+//
+// Returns an error if recipients, subject, or otp is missing or a recipient address
+// is invalid.
+func NewOTPEmail(recipients []EmailContact, subject, otp string) (EmailKafkaMessage, error) {
+	return newEmail(EmailKafkaMessage{
+		Recipients: recipients,
+		Subject:    subject,
+		Type:       EmailTypeOTP,
+		OTPCode:    otp,
+	})
+}
+
+// NewTransactionEmail creates a transaction EmailKafkaMessage reporting details to
+// recipients.
+//
+// Returns an error if recipients, subject, or details is missing, a recipient address
+// is invalid, or details cannot be serialized.
+func NewTransactionEmail(recipients []EmailContact, subject string, details map[string]interface{}) (EmailKafkaMessage, error) {
+	return newEmail(EmailKafkaMessage{
+		Recipients:         recipients,
+		Subject:            subject,
+		Type:               EmailTypeTransaction,
+		TransactionDetails: details,
+	})
+}
+
+// NewMessageEmail creates a generic message EmailKafkaMessage sending body to
+// recipients.
+//
+// Returns an error if recipients, subject, or body is missing or a recipient address
+// is invalid.
+func NewMessageEmail(recipients []EmailContact, subject, body string) (EmailKafkaMessage, error) {
+	return newEmail(EmailKafkaMessage{
+		Recipients:  recipients,
+		Subject:     subject,
+		Type:        EmailTypeMessage,
+		MessageBody: body,
+	})
+}
+
+// newEmail validates that msg has a subject, then applies Validate.
+//
+// Returns msg together with the first validation error, if any.
+func newEmail(msg EmailKafkaMessage) (EmailKafkaMessage, error) {
+	err := validation.ValidateStruct(&msg,
+		validation.Field(&msg.Subject, validation.Required.Error("subject is required")),
+	)
+	if err != nil {
+		return msg, err
 	}
+	return msg, msg.Validate()
 }
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// MessageBodyEncodingGzip is the MessageBodyEncoding of an email whose MessageBody is
+// gzipped and then base64-encoded.
+const MessageBodyEncodingGzip = "gzip"
+
+// MaxDecodedMessageBodyBytes is the largest decompressed size accepted for a gzipped
+// MessageBody, so that a small malicious body cannot exhaust memory when decoded.
+const MaxDecodedMessageBodyBytes = 10 * 1024 * 1024
+
+// CompressMessageBody gzips MessageBody, base64-encodes it so it stays valid JSON
+// text, and sets MessageBodyEncoding to MessageBodyEncodingGzip. A body that is
+// already encoded is left unchanged.
+//
+// Returns an error if compression fails.
+func (e *EmailKafkaMessage) CompressMessageBody() error {
+	if e.MessageBodyEncoding != "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(e.MessageBody)); err != nil {
+		return fmt.Errorf("failed to compress message body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress message body: %w", err)
+	}
+
+	e.MessageBody = base64.StdEncoding.EncodeToString(buf.Bytes())
+	e.MessageBodyEncoding = MessageBodyEncodingGzip
+	return nil
+}
+
+// DecodedMessageBody returns MessageBody as originally written, decompressing it if
+// MessageBodyEncoding is MessageBodyEncodingGzip. Uncompressed bodies are returned
+// unchanged.
+//
+// Returns an error if the encoding is unknown, the body cannot be decompressed, or it
+// decompresses to more than MaxDecodedMessageBodyBytes.
+func (e EmailKafkaMessage) DecodedMessageBody() (string, error) {
+	switch e.MessageBodyEncoding {
+	case "":
+		return e.MessageBody, nil
+	case MessageBodyEncodingGzip:
+	default:
+		return "", fmt.Errorf("unknown message body encoding %q", e.MessageBodyEncoding)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(e.MessageBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode message body: %w", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress message body: %w", err)
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(io.LimitReader(reader, MaxDecodedMessageBodyBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress message body: %w", err)
+	}
+	if len(body) > MaxDecodedMessageBodyBytes {
+		return "", fmt.Errorf("decompressed message body exceeds limit of %d bytes", MaxDecodedMessageBodyBytes)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+// Expiring is implemented by message DTOs that carry an optional expiry, after which
+// the notification is stale and should not be delivered.
+type Expiring interface {
+	MessageExpiry() *time.Time
+}
@@ -14,4 +14,15 @@ type InAppKafkaMessage struct {
 	ExpiresAt *time.Time             `json:"expires_at,omitempty"`
 	Priority  int                    `json:"priority,omitempty"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Locale    string                 `json:"locale,omitempty"` // BCP 47 language tag, e.g. "am" or "en"
+}
+
+// MessageLocale returns the locale of the in-app notification.
+func (i InAppKafkaMessage) MessageLocale() string {
+	return i.Locale
+}
+
+// MessageExpiry returns the expiry of the in-app notification, or nil if it does not expire.
+func (i InAppKafkaMessage) MessageExpiry() *time.Time {
+	return i.ExpiresAt
 }
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Localized is implemented by message DTOs that carry a locale used to select the
+// template or translation for the notification content.
+type Localized interface {
+	MessageLocale() string
+}
+
+// ParseLocale validates a BCP 47 language tag (e.g., "am", "en-US") and returns its
+// canonical form.
+//
+// Returns an error if locale is not a well-formed language tag.
+func ParseLocale(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	return tag.String(), nil
+}
+
+// validateLocale is an ozzo-validation rule for optional BCP 47 locale fields.
+func validateLocale(value interface{}) error {
+	locale, _ := value.(string)
+	if locale == "" {
+		return nil
+	}
+	_, err := ParseLocale(locale)
+	return err
+}
@@ -1,10 +1,23 @@
 package dto
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"time"
 )
 
+// SchemaVersion is the version of the payload shapes defined in this package. It is
+// bumped whenever a DTO changes incompatibly, and producers write it to the
+// schema_version header so that consumers can decode old and new payloads side by
+// side during a rollout.
+const SchemaVersion = 1
+
+// ErrEmptyPayload is returned when a notification message is created without a payload.
+var ErrEmptyPayload = errors.New("notification payload is empty")
+
 // NotificationMessage represents the base structure for Kafka messages
 type NotificationMessage struct {
 	ID        string                 `json:"id"`
@@ -14,8 +27,42 @@ type NotificationMessage struct {
 	Headers   map[string]interface{} `json:"headers,omitempty"`
 }
 
-// NewNotificationMessage creates a new NotificationMessage with marshaled payload
+// UnmarshalJSON decodes a NotificationMessage, accepting created_at either as an
+// RFC 3339 string or as Unix epoch milliseconds, as written by producers that use
+// epoch-millisecond times.
+func (n *NotificationMessage) UnmarshalJSON(data []byte) error {
+	type message NotificationMessage
+	aux := struct {
+		*message
+		CreatedAt json.RawMessage `json:"created_at"`
+	}{message: (*message)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.CreatedAt) == 0 || string(aux.CreatedAt) == "null" {
+		return nil
+	}
+	if aux.CreatedAt[0] == '"' {
+		return json.Unmarshal(aux.CreatedAt, &n.CreatedAt)
+	}
+	var millis int64
+	if err := json.Unmarshal(aux.CreatedAt, &millis); err != nil {
+		return fmt.Errorf("invalid created_at: %w", err)
+	}
+	n.CreatedAt = time.UnixMilli(millis)
+	return nil
+}
+
+// NewNotificationMessage creates a new NotificationMessage with marshaled payload.
+//
+// Returns ErrEmptyPayload if payload is nil or a nil pointer, map, or slice, which
+// would otherwise be published as a null payload, or any marshaling error.
 func NewNotificationMessage(id, msgType string, payload interface{}) (*NotificationMessage, error) {
+	if isNil(payload) {
+		return nil, fmt.Errorf("%w: %s message", ErrEmptyPayload, msgType)
+	}
+
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
@@ -30,7 +77,36 @@ func NewNotificationMessage(id, msgType string, payload interface{}) (*Notificat
 	}, nil
 }
 
-// UnmarshalPayload unmarshals the payload into the provided struct
+// UnmarshalPayload unmarshals the payload into the provided struct.
+// Fields in the payload that v does not declare are ignored.
 func (n *NotificationMessage) UnmarshalPayload(v interface{}) error {
 	return json.Unmarshal(n.Payload, v)
 }
+
+// UnmarshalPayloadStrict unmarshals the payload into the provided struct, returning
+// an error if the payload contains fields that v does not declare. Use it in
+// consumers that must enforce the payload contract.
+func (n *NotificationMessage) UnmarshalPayloadStrict(v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(n.Payload))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// RawPayload returns the undecoded payload, including any fields that a struct
+// passed to UnmarshalPayload would drop.
+func (n *NotificationMessage) RawPayload() json.RawMessage {
+	return n.Payload
+}
+
+// isNil reports whether v is nil or holds a nil pointer, map, slice, or interface.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -1,11 +1,25 @@
 package dto
 
+import "time"
+
 // SMSKafkaMessage represents an SMS message received from Kafka
 type SMSKafkaMessage struct {
 	Recipient   string                 `json:"recipient"`
 	MessageBody string                 `json:"message_body"`
 	Priority    int                    `json:"priority,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Locale      string                 `json:"locale,omitempty"` // BCP 47 language tag, e.g. "am" or "en"
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+}
+
+// MessageLocale returns the locale of the SMS message.
+func (s SMSKafkaMessage) MessageLocale() string {
+	return s.Locale
+}
+
+// MessageExpiry returns the expiry of the SMS message, or nil if it does not expire.
+func (s SMSKafkaMessage) MessageExpiry() *time.Time {
+	return s.ExpiresAt
 }
 
 // PushKafkaMessage represents a push notification message received from Kafka
@@ -21,6 +35,18 @@ type PushKafkaMessage struct {
 	Sound        string                   `json:"sound,omitempty"`
 	ClickAction  string                   `json:"click_action,omitempty"`
 	Metadata     map[string]interface{}   `json:"metadata,omitempty"`
+	Locale       string                   `json:"locale,omitempty"` // BCP 47 language tag, e.g. "am" or "en"
+	ExpiresAt    *time.Time               `json:"expires_at,omitempty"`
+}
+
+// MessageLocale returns the locale of the push notification.
+func (p PushKafkaMessage) MessageLocale() string {
+	return p.Locale
+}
+
+// MessageExpiry returns the expiry of the push notification, or nil if it does not expire.
+func (p PushKafkaMessage) MessageExpiry() *time.Time {
+	return p.ExpiresAt
 }
 
 // PushNotificationPriority represents the priority of a push notification
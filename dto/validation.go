@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ValidationErrors maps every invalid field, by its JSON path such as "subject" or
+// "recipients.0.email", to why it is invalid. It is returned by the ValidateDetailed
+// methods and, wrapped, by the producer when it rejects a message, so HTTP handlers
+// can derive a per-field 400 response with errors.As.
+type ValidationErrors map[string]string
+
+// Error returns the field errors in field order, e.g.
+// "recipients: recipients are required; subject: subject is required".
+func (v ValidationErrors) Error() string {
+	parts := make([]string, 0, len(v))
+	for _, field := range slices.Sorted(maps.Keys(v)) {
+		if field == "" {
+			parts = append(parts, v[field])
+			continue
+		}
+		parts = append(parts, field+": "+v[field])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// detailedErrors flattens err, as returned by ozzo-validation, into ValidationErrors.
+//
+// Returns nil if err is nil.
+func detailedErrors(err error) ValidationErrors {
+	if err == nil {
+		return nil
+	}
+	details := make(ValidationErrors)
+	flattenErrors(details, "", err)
+	return details
+}
+
+// flattenErrors adds err to details under path, descending into nested field errors.
+func flattenErrors(details ValidationErrors, path string, err error) {
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		details[path] = err.Error()
+		return
+	}
+	for field, fieldErr := range fieldErrs {
+		if fieldErr == nil {
+			continue
+		}
+		if path != "" {
+			field = path + "." + field
+		}
+		flattenErrors(details, field, fieldErr)
+	}
+}
@@ -1,49 +1,259 @@
 // Package initiator provides initialization and cleanup logic
-// for notification-related services such as Kafka producer and configuration loading.
+// for notification-related services such as Kafka producer, consumer, and configuration loading.
 package initiator
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dawit-go/notification-kafka-lib/admin"
 	"github.com/dawit-go/notification-kafka-lib/config"
+	"github.com/dawit-go/notification-kafka-lib/consumer"
+	"github.com/dawit-go/notification-kafka-lib/metrics"
 	producer "github.com/dawit-go/notification-kafka-lib/producer"
 	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // NotificationServices holds initialized notification-related services and configuration.
 type NotificationServices struct {
-	Producer *producer.NotificationProducer // Kafka producer instance for publishing messages
+	Producer producer.Producer              // Kafka producer for publishing messages
+	Consumer *consumer.NotificationConsumer // Kafka consumer instance, if created with WithConsumer
 	Config   *config.ConfigParsed           // Loaded configuration including email and Kafka settings
+	metrics  *metrics.Registry              // Registry shared by all notification components; nil when WithMeter is used
+}
+
+// Option configures InitializeNotificationServices.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	registry *metrics.Registry
+	meter    metric.Meter
+
+	consumerTopics []string          // Topics to consume; a consumer is created only if set
+	consumerOpts   []consumer.Option // Options for the created consumer
+}
+
+// WithMetricsRegistry wires registry into every component created by
+// InitializeNotificationServices, so all emitted metrics share one registry.
+// When omitted, a new registry is created.
+func WithMetricsRegistry(registry *metrics.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithMeter reports publish counts, error counts, and latency through meter using a
+// metrics.OTelRecorder instead of the in-memory registry, for services standardized
+// on OpenTelemetry. It cannot be combined with WithMetricsRegistry, and Metrics
+// returns zero counters when it is used.
+func WithMeter(meter metric.Meter) Option {
+	return func(o *options) {
+		o.meter = meter
+	}
+}
+
+// WithConsumer also creates a NotificationConsumer subscribed to topics with the
+// given options, available as NotificationServices.Consumer. Register its handlers and
+// call Start; Cleanup stops it before closing the producer. Handler durations are
+// recorded to the same metrics as the producer unless opts include
+// consumer.WithMetrics.
+func WithConsumer(topics []string, opts ...consumer.Option) Option {
+	return func(o *options) {
+		o.consumerTopics = topics
+		o.consumerOpts = opts
+	}
 }
 
 // InitializeNotificationServices loads configuration from Vault and
 // creates a Kafka NotificationProducer instance with the given logger.
+// A nil logger is replaced with a no-op logger. Options customize the
+// created services.
+//
+// When AutoCreateTopics is enabled, missing notification topics are created first.
 //
-// Returns initialized NotificationServices or an error if loading config or
-// producer creation fails.
-func InitializeNotificationServices(logger utils.Logger) (*NotificationServices, error) {
+// Returns initialized NotificationServices or an error if loading config, topic
+// creation, or producer or consumer creation fails.
+func InitializeNotificationServices(logger utils.Logger, opts ...Option) (*NotificationServices, error) {
+	var log producer.Logger = producer.NopLogger()
+	if logger != nil {
+		log = logger
+	}
+
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	recorder, err := o.recorder()
+	if err != nil {
+		log.Errorf("Failed to configure metrics: %v", err)
+		return nil, err
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
-		logger.Errorf("Failed to load config: %v", err)
+		log.Errorf("Failed to load config: %v", err)
 		return nil, err
 	}
 
-	prod, err := producer.NewNotificationProducer(cfg.Kafka, logger)
+	if cfg.Kafka.AutoCreateTopics {
+		if err := ensureTopics(cfg.Kafka, logger); err != nil {
+			log.Errorf("Failed to ensure Kafka topics: %v", err)
+			return nil, err
+		}
+	}
+
+	prod, err := producer.NewNotificationProducer(cfg.Kafka, logger, producer.WithMetrics(recorder))
 	if err != nil {
-		logger.Errorf("Failed to initialize Kafka producer: %v", err)
+		log.Errorf("Failed to initialize Kafka producer: %v", err)
 		return nil, err
 	}
 
-	logger.Infof("Notification services initialized successfully")
+	var cons *consumer.NotificationConsumer
+	if len(o.consumerTopics) > 0 {
+		consumerOpts := o.consumerOpts
+		if handlerRecorder, ok := recorder.(metrics.HandlerRecorder); ok {
+			consumerOpts = append([]consumer.Option{consumer.WithMetrics(handlerRecorder)}, consumerOpts...)
+		}
+		if cons, err = consumer.NewNotificationConsumer(cfg.Kafka, o.consumerTopics, logger, consumerOpts...); err != nil {
+			log.Errorf("Failed to initialize Kafka consumer: %v", err)
+			_ = prod.Close()
+			return nil, err
+		}
+	}
+
+	log.Infof("Notification services initialized successfully")
 
 	return &NotificationServices{
 		Producer: prod,
+		Consumer: cons,
 		Config:   cfg,
+		metrics:  o.registry,
 	}, nil
 }
 
-// Cleanup gracefully closes any active connections or resources,
-// such as the Kafka producer, to ensure clean shutdown.
-func (ns *NotificationServices) Cleanup() {
+// NewNotificationServices assembles NotificationServices from cfg and prod without
+// loading configuration or connecting to Kafka, so that code depending on
+// NotificationServices can be unit tested with a fake producer.Producer. No consumer
+// is created, and Metrics returns zero counters.
+func NewNotificationServices(cfg *config.ConfigParsed, prod producer.Producer) *NotificationServices {
+	return &NotificationServices{
+		Producer: prod,
+		Config:   cfg,
+	}
+}
+
+// recorder returns the metrics.Recorder selected by the options: an OTelRecorder when
+// WithMeter is used, otherwise the given or a new Registry, which is stored in o.
+//
+// Returns an error if both WithMeter and WithMetricsRegistry are used, or if the
+// OpenTelemetry instruments cannot be created.
+func (o *options) recorder() (metrics.Recorder, error) {
+	if o.meter == nil {
+		if o.registry == nil {
+			o.registry = metrics.NewRegistry()
+		}
+		return o.registry, nil
+	}
+	if o.registry != nil {
+		return nil, fmt.Errorf("WithMeter and WithMetricsRegistry are mutually exclusive")
+	}
+	return metrics.NewOTelRecorder(o.meter)
+}
+
+// Metrics returns the aggregate counters from the shared metrics registry.
+func (ns *NotificationServices) Metrics() metrics.Counters {
+	if ns.metrics == nil {
+		return metrics.Counters{}
+	}
+	return ns.metrics.Counters()
+}
+
+// ensureTopics creates any missing notification topics using a short-lived admin client.
+func ensureTopics(cfg config.KafkaConfig, logger utils.Logger) error {
+	kafkaAdmin, err := admin.NewNotificationAdmin(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer kafkaAdmin.Close()
+
+	return kafkaAdmin.EnsureTopics(context.Background(), kafkaAdmin.DefaultTopicSpecs())
+}
+
+// WaitForReady blocks until the producer can reach the Kafka brokers or ctx expires,
+// polling the producer's health check once per second. Use it to hold back readiness
+// until the service can actually publish.
+//
+// Returns nil once Kafka is reachable, or the last health check error joined with
+// the context error if ctx expires first.
+func (ns *NotificationServices) WaitForReady(ctx context.Context) error {
+	if ns.Producer == nil {
+		return fmt.Errorf("notification producer not initialized")
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		err := ns.Producer.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(err, ctx.Err()) {
+				return err
+			}
+			return errors.Join(ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cleanup gracefully shuts down the notification services in order, bounded by ctx:
+// the consumer first, which stops consuming and waits for in-flight handlers, then
+// the producer, so that status events and dead-letter messages that handlers publish
+// while draining are still delivered. If ctx is done first, Cleanup returns without
+// waiting further; closing continues in the background.
+//
+// Returns nil on a clean shutdown, or an error joining every component that failed to
+// close or did not close before ctx was done.
+func (ns *NotificationServices) Cleanup(ctx context.Context) error {
+	var errs []error
+	if ns.Consumer != nil {
+		if err := closeWithin(ctx, ns.Consumer.Close); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close consumer: %w", err))
+		}
+	}
 	if ns.Producer != nil {
-		ns.Producer.Close()
+		closeProducer := func() error {
+			_, err := ns.Producer.CloseContext(ctx)
+			return err
+		}
+		if err := closeWithin(ctx, closeProducer); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close producer: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeWithin runs closeFn and waits for it to return or for ctx to be done.
+//
+// Returns the error from closeFn, or the context error if ctx is done first.
+func closeWithin(ctx context.Context, closeFn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- closeFn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,72 @@
+package kafkautil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dawit-go/notification-kafka-lib/config"
+)
+
+// HeaderEncrypted is set to "true" on messages whose payload is encrypted.
+const HeaderEncrypted = "encrypted"
+
+// NewPayloadCipher returns the AES-GCM cipher for the base64-encoded
+// PayloadEncryptionKey of cfg, or nil if no key is configured.
+//
+// Returns an error if the key is not valid base64 or not 16, 24, or 32 bytes long.
+func NewPayloadCipher(cfg config.KafkaConfig) (cipher.AEAD, error) {
+	if cfg.PayloadEncryptionKey == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.PayloadEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PayloadEncryptionKey: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PayloadEncryptionKey: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptPayload seals payload with aead, bound to the message id, and returns it as
+// a JSON string holding the base64-encoded nonce and ciphertext, so the envelope
+// stays valid JSON.
+//
+// Returns an error if a nonce cannot be generated.
+func EncryptPayload(aead cipher.AEAD, id string, payload json.RawMessage) (json.RawMessage, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, payload, []byte(id))
+	return json.Marshal(base64.StdEncoding.EncodeToString(sealed))
+}
+
+// DecryptPayload reverses EncryptPayload for the message id.
+//
+// Returns an error if payload is not an encrypted payload or fails authentication,
+// for example because it was encrypted with another key or for another message.
+func DecryptPayload(aead cipher.AEAD, id string, payload json.RawMessage) (json.RawMessage, error) {
+	var encoded string
+	if err := json.Unmarshal(payload, &encoded); err != nil {
+		return nil, fmt.Errorf("encrypted payload is not a string: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted payload is not base64: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted payload is too short")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plain, nil
+}
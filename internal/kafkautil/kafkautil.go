@@ -0,0 +1,99 @@
+// Package kafkautil holds the Sarama setup shared by the producer and consumer packages.
+package kafkautil
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/config"
+)
+
+// Brokers splits the comma-separated broker list from cfg, trimming whitespace.
+//
+// When cfg.Brokers is instead a DNS SRV name, such as "_kafka._tcp.example.com", it is
+// resolved into the host:port of every target, in priority and weight order. It is
+// resolved only at startup; after connecting, Sarama learns broker changes from
+// cluster metadata, so the SRV record only needs to list live bootstrap brokers.
+//
+// Returns an error if no brokers are configured or the SRV lookup fails.
+func Brokers(cfg config.KafkaConfig) ([]string, error) {
+	if cfg.Brokers == "" {
+		return nil, fmt.Errorf("Kafka brokers not configured")
+	}
+	if isSRVName(cfg.Brokers) {
+		return lookupSRV(strings.TrimSpace(cfg.Brokers))
+	}
+
+	brokers := strings.Split(cfg.Brokers, ",")
+	for i, broker := range brokers {
+		brokers[i] = strings.TrimSpace(broker)
+	}
+	return brokers, nil
+}
+
+// isSRVName reports whether brokers is a single DNS SRV name rather than a broker
+// list; SRV names begin with an underscore-prefixed service label.
+func isSRVName(brokers string) bool {
+	brokers = strings.TrimSpace(brokers)
+	return strings.HasPrefix(brokers, "_") && !strings.ContainsAny(brokers, ",:")
+}
+
+// lookupSRV resolves the SRV record name into host:port broker addresses.
+//
+// Returns an error if the lookup fails or yields no targets.
+func lookupSRV(name string) ([]string, error) {
+	_, records, err := net.LookupSRV("", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Kafka brokers from SRV record %s: %w", name, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("SRV record %s has no Kafka brokers", name)
+	}
+
+	brokers := make([]string, len(records))
+	for i, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		brokers[i] = net.JoinHostPort(host, strconv.Itoa(int(record.Port)))
+	}
+	return brokers, nil
+}
+
+// NewSaramaConfig returns a Sarama config with the protocol version, client ID,
+// network timeouts, metadata refresh settings, and SASL settings from cfg applied.
+// Callers add their producer or consumer options on top.
+func NewSaramaConfig(cfg config.KafkaConfig) *sarama.Config {
+	kafkaConfig := sarama.NewConfig()
+	kafkaConfig.Version = sarama.V2_6_0_0
+	if cfg.ClientID != "" {
+		kafkaConfig.ClientID = cfg.ClientID
+	}
+	if cfg.DialTimeoutMs > 0 {
+		kafkaConfig.Net.DialTimeout = time.Duration(cfg.DialTimeoutMs) * time.Millisecond
+	}
+	if cfg.ReadTimeoutMs > 0 {
+		kafkaConfig.Net.ReadTimeout = time.Duration(cfg.ReadTimeoutMs) * time.Millisecond
+	}
+	if cfg.WriteTimeoutMs > 0 {
+		kafkaConfig.Net.WriteTimeout = time.Duration(cfg.WriteTimeoutMs) * time.Millisecond
+	}
+	if cfg.MetadataRefreshIntervalMs > 0 {
+		kafkaConfig.Metadata.RefreshFrequency = time.Duration(cfg.MetadataRefreshIntervalMs) * time.Millisecond
+	}
+	if cfg.MetadataRetryMax > 0 {
+		kafkaConfig.Metadata.Retry.Max = cfg.MetadataRetryMax
+	}
+
+	if cfg.SASLEnabled {
+		kafkaConfig.Net.SASL.Enable = true
+		kafkaConfig.Net.SASL.User = cfg.SASLUsername
+		kafkaConfig.Net.SASL.Password = cfg.SASLPassword
+		kafkaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(strings.ToUpper(cfg.SASLMechanism))
+		kafkaConfig.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(kafkaConfig.Net.SASL.Mechanism)
+	}
+
+	return kafkaConfig
+}
@@ -0,0 +1,52 @@
+package kafkautil
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// scramClient implements sarama.SCRAMClient using xdg-go/scram.
+type scramClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+// newSCRAMClientGenerator returns the Sarama SCRAM client generator for mechanism, or
+// nil if mechanism is not a SCRAM mechanism.
+func newSCRAMClientGenerator(mechanism sarama.SASLMechanism) func() sarama.SCRAMClient {
+	var hashGenerator scram.HashGeneratorFcn
+	switch mechanism {
+	case sarama.SASLTypeSCRAMSHA256:
+		hashGenerator = sha256.New
+	case sarama.SASLTypeSCRAMSHA512:
+		hashGenerator = sha512.New
+	default:
+		return nil
+	}
+	return func() sarama.SCRAMClient {
+		return &scramClient{hashGenerator: hashGenerator}
+	}
+}
+
+// Begin starts a SCRAM conversation for the given credentials.
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+// Step returns the response to the server's challenge.
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+// Done reports whether the conversation has completed.
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
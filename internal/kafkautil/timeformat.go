@@ -0,0 +1,86 @@
+package kafkautil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// HeaderTimeFormat names the encoding of the time fields of a message's JSON when it
+// is not Go's default RFC 3339.
+const HeaderTimeFormat = "time_format"
+
+// TimeFormatEpochMillis is the HeaderTimeFormat value for times encoded as Unix epoch
+// milliseconds.
+const TimeFormatEpochMillis = "epoch_millis"
+
+// timeFields are the payload keys that hold the times of the notification DTOs. Only
+// these top-level keys are converted; free-form maps such as Metadata and
+// TransactionDetails belong to the caller and are never touched.
+var timeFields = []string{"created_at", "expires_at"}
+
+// EpochMillisTimes re-encodes payload with the RFC 3339 strings held by its top-level
+// time fields, created_at and expires_at, replaced by their Unix epoch milliseconds.
+// All other fields are preserved byte for byte.
+//
+// Returns payload unchanged if it is not a JSON object.
+func EpochMillisTimes(payload json.RawMessage) (json.RawMessage, error) {
+	return convertTimes(payload, func(value json.RawMessage) json.RawMessage {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return value
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return value
+		}
+		return json.RawMessage(strconv.FormatInt(t.UnixMilli(), 10))
+	})
+}
+
+// RFC3339Times reverses EpochMillisTimes, replacing the integers held by the
+// top-level time fields of payload with the RFC 3339 strings of that many Unix epoch
+// milliseconds, in UTC.
+//
+// Returns payload unchanged if it is not a JSON object.
+func RFC3339Times(payload json.RawMessage) (json.RawMessage, error) {
+	return convertTimes(payload, func(value json.RawMessage) json.RawMessage {
+		millis, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return value
+		}
+		converted, _ := json.Marshal(time.UnixMilli(millis).UTC().Format(time.RFC3339Nano))
+		return converted
+	})
+}
+
+// convertTimes re-encodes payload with the values of its top-level time fields
+// replaced by convert.
+func convertTimes(payload json.RawMessage, convert func(json.RawMessage) json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return payload, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, key := range timeFields {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if converted := convert(value); !bytes.Equal(converted, value) {
+			fields[key] = converted
+			changed = true
+		}
+	}
+	if !changed {
+		return payload, nil
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,96 @@
+// Package metrics provides a shared registry for notification service metrics so the
+// producer and consumer report into a single place.
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Recorder receives metric events from the notification services.
+type Recorder interface {
+	// RecordPublish records the outcome and latency of a single publish attempt.
+	RecordPublish(msgType, topic string, latency time.Duration, err error)
+}
+
+// Handler outcomes passed to HandlerRecorder.RecordHandle.
+const (
+	OutcomeSuccess = "success" // The handler returned nil
+	OutcomeError   = "error"   // The handler returned an error
+	OutcomePanic   = "panic"   // The handler panicked
+)
+
+// HandlerRecorder receives metric events from notification consumers.
+type HandlerRecorder interface {
+	// RecordHandle records the wall time of a single handler invocation for a message
+	// of msgType, and its outcome: OutcomeSuccess, OutcomeError, or OutcomePanic.
+	RecordHandle(msgType, outcome string, duration time.Duration)
+}
+
+// StaleRecorder receives stale-message events from notification consumers. A
+// HandlerRecorder passed to the consumer's WithMetrics that also implements
+// StaleRecorder is notified of every message dropped for its age.
+type StaleRecorder interface {
+	// RecordStale records that a message of msgType consumed from topic was skipped
+	// because it was too old.
+	RecordStale(msgType, topic string)
+}
+
+// Counters is a point-in-time snapshot of the aggregate counters in a Registry.
+type Counters struct {
+	Published     int64 // Messages successfully published
+	Errors        int64 // Publish attempts that failed
+	Handled       int64 // Handler invocations that succeeded
+	HandlerErrors int64 // Handler invocations that returned an error or panicked
+	Stale         int64 // Consumed messages skipped because they were too old
+}
+
+// Registry is a Recorder, HandlerRecorder, and StaleRecorder that keeps aggregate
+// counters in memory. It is safe for concurrent use and cheap enough to poll
+// frequently.
+type Registry struct {
+	published     atomic.Int64
+	errors        atomic.Int64
+	handled       atomic.Int64
+	handlerErrors atomic.Int64
+	stale         atomic.Int64
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// RecordPublish implements Recorder.
+func (r *Registry) RecordPublish(_, _ string, _ time.Duration, err error) {
+	if err != nil {
+		r.errors.Add(1)
+		return
+	}
+	r.published.Add(1)
+}
+
+// RecordHandle implements HandlerRecorder.
+func (r *Registry) RecordHandle(_, outcome string, _ time.Duration) {
+	if outcome != OutcomeSuccess {
+		r.handlerErrors.Add(1)
+		return
+	}
+	r.handled.Add(1)
+}
+
+// RecordStale implements StaleRecorder.
+func (r *Registry) RecordStale(_, _ string) {
+	r.stale.Add(1)
+}
+
+// Counters returns a snapshot of the aggregate counters.
+func (r *Registry) Counters() Counters {
+	return Counters{
+		Published:     r.published.Load(),
+		Errors:        r.errors.Load(),
+		Handled:       r.handled.Load(),
+		HandlerErrors: r.handlerErrors.Load(),
+		Stale:         r.stale.Load(),
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OTelRecorder is a Recorder that reports publish counts, error counts, and latency
+// through an OpenTelemetry metric.Meter. Every measurement carries the message type
+// and topic as the "type" and "topic" attributes. As a HandlerRecorder it reports
+// handler durations with the "type" and "outcome" attributes, and as a StaleRecorder
+// it counts stale messages with the "type" and "topic" attributes.
+type OTelRecorder struct {
+	published metric.Int64Counter
+	errors    metric.Int64Counter
+	latency   metric.Float64Histogram
+	handling  metric.Float64Histogram
+	stale     metric.Int64Counter
+}
+
+// NewOTelRecorder creates an OTelRecorder whose instruments are registered on meter:
+// notification.publish.count, notification.publish.errors,
+// notification.publish.duration, notification.handler.duration (both in seconds), and
+// notification.consumer.stale.
+//
+// Returns an error if any instrument cannot be created.
+func NewOTelRecorder(meter metric.Meter) (*OTelRecorder, error) {
+	published, err := meter.Int64Counter("notification.publish.count",
+		metric.WithDescription("Messages successfully published"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create publish counter: %w", err)
+	}
+
+	errors, err := meter.Int64Counter("notification.publish.errors",
+		metric.WithDescription("Publish attempts that failed"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create publish error counter: %w", err)
+	}
+
+	latency, err := meter.Float64Histogram("notification.publish.duration",
+		metric.WithDescription("Latency of publish attempts"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create publish latency histogram: %w", err)
+	}
+
+	handling, err := meter.Float64Histogram("notification.handler.duration",
+		metric.WithDescription("Wall time of consumer handler invocations"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create handler duration histogram: %w", err)
+	}
+
+	stale, err := meter.Int64Counter("notification.consumer.stale",
+		metric.WithDescription("Consumed messages skipped because they were too old"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stale message counter: %w", err)
+	}
+
+	return &OTelRecorder{
+		published: published,
+		errors:    errors,
+		latency:   latency,
+		handling:  handling,
+		stale:     stale,
+	}, nil
+}
+
+// RecordPublish implements Recorder.
+func (r *OTelRecorder) RecordPublish(msgType, topic string, latency time.Duration, err error) {
+	ctx := context.Background()
+	attrs := metric.WithAttributes(
+		attribute.String("type", msgType),
+		attribute.String("topic", topic),
+	)
+
+	r.latency.Record(ctx, latency.Seconds(), attrs)
+	if err != nil {
+		r.errors.Add(ctx, 1, attrs)
+		return
+	}
+	r.published.Add(ctx, 1, attrs)
+}
+
+// RecordHandle implements HandlerRecorder.
+func (r *OTelRecorder) RecordHandle(msgType, outcome string, duration time.Duration) {
+	r.handling.Record(context.Background(), duration.Seconds(), metric.WithAttributes(
+		attribute.String("type", msgType),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// RecordStale implements StaleRecorder.
+func (r *OTelRecorder) RecordStale(msgType, topic string) {
+	r.stale.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("type", msgType),
+		attribute.String("topic", topic),
+	))
+}
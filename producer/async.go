@@ -0,0 +1,314 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// WithFireAndForget switches the producer to fire-and-forget mode: publishes are
+// enqueued on a Sarama AsyncProducer and return as soon as the message is buffered,
+// without waiting for the broker. Delivery failures are only logged, or reported with
+// WithDeliveryReports, so a nil error means the message was enqueued, not delivered.
+// Sarama does not report successful deliveries in this mode, which saves the
+// per-message confirmation overhead, so Stats counts these messages as Enqueued and,
+// if they fail, as Failed, but never as Delivered or InFlight. WithDeliveryReports
+// turns success reporting back on, and with it the Delivered and InFlight counts.
+//
+// Use it only for paths that tolerate loss, such as metrics-style ingest. Close still
+// drains the buffer, delivering every enqueued message before it returns; use
+// CloseContext to bound how long it waits. Per-call
+// WithRequiredAcks is ignored in this mode, and PublishEmailBatch still waits for
+// delivery confirmation. Messages wait in a queue, sized by the ChannelBufferSize
+// config, until Sarama accepts them; WithQueuePolicy selects what a publish does when
+// the queue is full.
+func WithFireAndForget() Option {
+	return func(np *NotificationProducer) {
+		np.fireAndForget = true
+	}
+}
+
+// QueuePolicy selects what a fire-and-forget publish does when the queue is full,
+// because publishes outpace delivery to Kafka.
+type QueuePolicy int
+
+const (
+	// QueueBlock waits for room in the queue, until the publish timeout or ctx is done.
+	// This is the default.
+	QueueBlock QueuePolicy = iota
+	// QueueDropOldest discards the oldest queued message to make room for the new one,
+	// whose publish then succeeds. Discarded messages are reported with ErrQueueFull on
+	// DeliveryReports and counted in Stats.Overflowed.
+	QueueDropOldest
+	// QueueError fails the publish immediately with an error wrapping ErrQueueFull and
+	// ErrRetriable, and counts it in Stats.Overflowed.
+	QueueError
+)
+
+// WithQueuePolicy sets what a fire-and-forget publish does when the queue is full, so
+// that an overloaded producer does not stall the request path. Stats.BufferDepth
+// reports the number of queued messages. It has no effect without WithFireAndForget.
+func WithQueuePolicy(policy QueuePolicy) Option {
+	return func(np *NotificationProducer) {
+		np.queuePolicy = policy
+	}
+}
+
+// defaultDeliveryReportBuffer is the capacity of the DeliveryReports channel when
+// WithDeliveryReports is given a size of zero or less.
+const defaultDeliveryReportBuffer = 1024
+
+// DeliveryReport is the delivery outcome of a single fire-and-forget publish.
+type DeliveryReport struct {
+	MessageID string // ID of the published NotificationMessage
+	Topic     string // Topic the message was published to
+	Partition int32  // Partition the message was written to; -1 on failure
+	Offset    int64  // Offset the message was written at; -1 on failure
+	Err       error  // Why the message was not delivered, or nil on success
+}
+
+// WithDeliveryReports makes the fire-and-forget producer report the outcome of every
+// message on the DeliveryReports channel, buffered to size reports (default 1024), so
+// callers can correlate deliveries with their own bookkeeping by message ID. It makes
+// Sarama return every success, adding back part of the overhead fire-and-forget mode
+// avoids. It has no effect without WithFireAndForget.
+//
+// The caller must drain DeliveryReports continuously: once the buffer is full,
+// delivery outcomes back up into Sarama and publishes eventually block.
+func WithDeliveryReports(size int) Option {
+	return func(np *NotificationProducer) {
+		if size <= 0 {
+			size = defaultDeliveryReportBuffer
+		}
+		np.reportBuffer = size
+	}
+}
+
+// DeliveryReports returns the channel on which fire-and-forget delivery outcomes are
+// reported. It is closed by Close once every enqueued message has been reported.
+//
+// Returns nil unless the producer was created with both WithFireAndForget and
+// WithDeliveryReports.
+func (np *NotificationProducer) DeliveryReports() <-chan DeliveryReport {
+	return np.reports
+}
+
+// startAsync creates the fire-and-forget AsyncProducer and starts logging its errors.
+// With WithDeliveryReports, its successes are returned too, and counted and reported.
+//
+// Returns an error if the producer fails to initialize.
+func (np *NotificationProducer) startAsync() error {
+	kafkaConfig := newProducerConfig(np.config, sarama.WaitForAll)
+	kafkaConfig.Producer.Return.Successes = np.reportBuffer > 0
+	kafkaConfig.Producer.Return.Errors = true
+
+	async, err := sarama.NewAsyncProducer(np.brokers, kafkaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka async producer: %w", err)
+	}
+
+	np.async = async
+	np.closing = make(chan struct{})
+	np.queue = make(chan *sarama.ProducerMessage, max(kafkaConfig.ChannelBufferSize, 1))
+	np.queueStop = make(chan struct{})
+	np.queueDone = make(chan struct{})
+	go np.pump()
+	if np.reportBuffer > 0 {
+		np.reports = make(chan DeliveryReport, np.reportBuffer)
+	}
+
+	var wg sync.WaitGroup
+	if np.reports != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range async.Successes() {
+				np.stats.delivered.Add(1)
+				np.report(DeliveryReport{
+					MessageID: messageID(msg),
+					Topic:     msg.Topic,
+					Partition: msg.Partition,
+					Offset:    msg.Offset,
+				})
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range async.Errors() {
+			if np.reports == nil {
+				np.stats.untrackedFailed.Add(1)
+			}
+			np.stats.failed.Add(1)
+			id := messageID(err.Msg)
+			np.logger.Errorf("Fire-and-forget message failed | ID: %s | Topic: %s | Error: %v", id, err.Msg.Topic, err.Err)
+			np.report(DeliveryReport{
+				MessageID: id,
+				Topic:     err.Msg.Topic,
+				Partition: -1,
+				Offset:    -1,
+				Err:       fmt.Errorf("failed to produce message: %w", classifyError(err.Err)),
+			})
+		}
+	}()
+	if np.reports != nil {
+		go func() {
+			wg.Wait()
+			close(np.reports)
+		}()
+	}
+	return nil
+}
+
+// pump hands the queued messages to the AsyncProducer in order, until the queue is
+// stopped and emptied by closeAsync.
+func (np *NotificationProducer) pump() {
+	defer close(np.queueDone)
+	for {
+		select {
+		case msg := <-np.queue:
+			np.handOff(msg)
+		case <-np.queueStop:
+			for {
+				select {
+				case msg := <-np.queue:
+					np.handOff(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handOff passes msg to the AsyncProducer, waiting while Sarama's input buffer is
+// full.
+func (np *NotificationProducer) handOff(msg *sarama.ProducerMessage) {
+	np.async.Input() <- msg
+	np.stats.enqueued.Add(1)
+	if np.reports == nil {
+		np.stats.untracked.Add(1)
+	}
+}
+
+// closeAsync empties the queue into the AsyncProducer, closes it, flushing its
+// buffer, and waits for it to finish or for ctx to be done. If ctx is done first, the
+// flush is left running in the background and every message still queued or in
+// flight is counted as dropped; without WithDeliveryReports, in-flight messages are
+// not tracked, so only those still in the input buffer are counted.
+//
+// Returns the number of messages dropped, and an error if the flush was abandoned or
+// the AsyncProducer failed to close cleanly.
+func (np *NotificationProducer) closeAsync(ctx context.Context) (int, error) {
+	done := make(chan error, 1)
+	go func() {
+		close(np.queueStop)
+		<-np.queueDone
+		done <- np.async.Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			np.logger.Errorf("Error closing Kafka async producer: %v", err)
+			return 0, fmt.Errorf("failed to close Kafka async producer: %w", err)
+		}
+		return 0, nil
+	case <-ctx.Done():
+		dropped := int(np.Stats().InFlight)
+		if np.reports == nil {
+			dropped = len(np.async.Input())
+		}
+		dropped += len(np.queue)
+		np.logger.Errorf("Abandoned flush of Kafka async producer | Dropped: %d | Error: %v", dropped, ctx.Err())
+		return dropped, fmt.Errorf("failed to flush Kafka async producer, %d messages dropped: %w", dropped, ctxError(ctx))
+	}
+}
+
+// report sends report on the DeliveryReports channel, if enabled, blocking until the
+// caller has room for it.
+func (np *NotificationProducer) report(report DeliveryReport) {
+	if np.reports != nil {
+		np.reports <- report
+	}
+}
+
+// messageID returns the value of the message_id header of msg, or "" if it has none.
+func messageID(msg *sarama.ProducerMessage) string {
+	for _, header := range msg.Headers {
+		if string(header.Key) == "message_id" {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// enqueue queues kafkaMsg for the AsyncProducer without waiting for delivery,
+// applying the QueuePolicy if the queue is full. The enqueue is registered as in
+// progress under np.mu, like a synchronous send, but the lock is released before
+// waiting for room in the queue, so that a full queue does not hold up Close, Ping,
+// or other publishes.
+//
+// Returns ErrProducerClosed if the producer is closed, including while waiting for
+// room, an error wrapping ErrQueueFull under QueueError, or the error from ctxError
+// if ctx is done while the queue is full.
+func (np *NotificationProducer) enqueue(ctx context.Context, kafkaMsg *sarama.ProducerMessage) error {
+	np.mu.Lock()
+	if np.closed {
+		np.mu.Unlock()
+		return ErrProducerClosed
+	}
+	np.sending.Add(1)
+	np.mu.Unlock()
+	defer np.sending.Done()
+
+	switch np.queuePolicy {
+	case QueueError:
+		select {
+		case np.queue <- kafkaMsg:
+			return nil
+		default:
+			np.stats.overflowed.Add(1)
+			return fmt.Errorf("%w: %w", ErrRetriable, ErrQueueFull)
+		}
+	case QueueDropOldest:
+		for {
+			select {
+			case np.queue <- kafkaMsg:
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-np.queue:
+				np.discard(oldest)
+			default:
+			}
+		}
+	default:
+		select {
+		case np.queue <- kafkaMsg:
+			return nil
+		case <-np.closing:
+			return ErrProducerClosed
+		case <-ctx.Done():
+			return ctxError(ctx)
+		}
+	}
+}
+
+// discard drops msg from the queue under QueueDropOldest, reporting it as undelivered.
+func (np *NotificationProducer) discard(msg *sarama.ProducerMessage) {
+	np.stats.overflowed.Add(1)
+	id := messageID(msg)
+	np.logger.Errorf("Fire-and-forget queue full, dropping oldest message | ID: %s | Topic: %s", id, msg.Topic)
+	np.report(DeliveryReport{
+		MessageID: id,
+		Topic:     msg.Topic,
+		Partition: -1,
+		Offset:    -1,
+		Err:       ErrQueueFull,
+	})
+}
@@ -0,0 +1,290 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// BatchItemResult is the outcome of publishing a single message of a batch or of
+// PublishMulti.
+type BatchItemResult struct {
+	Index  int           // Index of the message in the slice passed to the publish call
+	Result PublishResult // Where the message was written; Partition and Offset are -1 on failure
+	Err    error         // Why the message was not published, or nil on success
+	// Dropped is set for a message silently discarded by a RateLimit with the
+	// RateLimitDrop policy, which has a nil Err but was not published.
+	Dropped bool
+}
+
+// BatchResult holds the per-message outcome of PublishEmailBatch, in batch order.
+type BatchResult struct {
+	Items    []BatchItemResult
+	messages []dto.EmailKafkaMessage
+}
+
+// Succeeded returns the number of messages that were published.
+func (r *BatchResult) Succeeded() int {
+	return len(r.Items) - r.Failed() - r.Dropped()
+}
+
+// Dropped returns the number of messages discarded by their RateLimit, which are
+// neither published nor failed.
+func (r *BatchResult) Dropped() int {
+	dropped := 0
+	for _, item := range r.Items {
+		if item.Dropped {
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Failed returns the number of messages that were not published.
+func (r *BatchResult) Failed() int {
+	failed := 0
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// FailedMessages returns the original messages that were not published, in batch
+// order, so that just those can be retried.
+func (r *BatchResult) FailedMessages() []dto.EmailKafkaMessage {
+	var failed []dto.EmailKafkaMessage
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed = append(failed, r.messages[item.Index])
+		}
+	}
+	return failed
+}
+
+// Err returns nil if every message was published, or an error joining each failure
+// annotated with its batch index.
+func (r *BatchResult) Err() error {
+	var errs []error
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("message %d: %w", item.Index, item.Err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d messages failed: %w", len(errs), len(r.Items), errors.Join(errs...))
+}
+
+// PublishEmailBatch publishes emailMsgs to the configured email topic in a single
+// produce call and waits for delivery confirmation. Messages that fail validation
+// or are rejected by the "email" RateLimit are not sent; the rest succeed or fail
+// individually. Messages dropped by the RateLimit are reported as neither failed nor
+// delivered, with Dropped set and Partition and Offset set to -1. Recipients are
+// deduplicated as by PublishEmailMessage. A WithIdempotencyKey or WithMessageID value
+// is suffixed with "-" and the message's index, so that every message of the batch is
+// distinct.
+//
+// Returns the per-message BatchResult, together with its Err if any message failed.
+// The result is never nil, so callers can retry result.FailedMessages().
+func (np *NotificationProducer) PublishEmailBatch(ctx context.Context, emailMsgs []dto.EmailKafkaMessage, opts ...PublishOption) (*BatchResult, error) {
+	topic := np.config.Topic(np.config.EmailTopic)
+	result := &BatchResult{
+		Items:    make([]BatchItemResult, len(emailMsgs)),
+		messages: emailMsgs,
+	}
+	if ctx.Err() != nil {
+		err := ctxError(ctx)
+		for i := range result.Items {
+			result.Items[i] = BatchItemResult{Index: i, Result: PublishResult{Topic: topic, Partition: -1, Offset: -1}, Err: err}
+		}
+		return result, result.Err()
+	}
+
+	start := time.Now()
+	ctx, cancel := np.withPublishTimeout(ctx)
+	defer cancel()
+
+	options := newPublishOptions(opts)
+	options.withContextHeaders(ctx)
+	notificationMsgs := make([]*dto.NotificationMessage, len(emailMsgs))
+	var kafkaMsgs []*sarama.ProducerMessage
+
+	for i, emailMsg := range emailMsgs {
+		item := &result.Items[i]
+		item.Index = i
+		item.Result = PublishResult{Topic: topic, Partition: -1, Offset: -1}
+
+		if err := requirePayload(emailMsg); err != nil {
+			item.Err = err
+			continue
+		}
+		emailMsg.Dedup()
+		if details := emailMsg.ValidateDetailed(); details != nil {
+			item.Err = fmt.Errorf("%w: invalid email message: %w", ErrFatal, details)
+			continue
+		}
+
+		drop, err := np.checkRateLimit("email")
+		if err != nil {
+			item.Err = err
+			continue
+		}
+		if drop {
+			item.Dropped = true
+			continue
+		}
+
+		msgOptions := options
+		compressed, err := np.compressEmailBody(&emailMsg)
+		if err != nil {
+			item.Err = err
+			continue
+		}
+		if compressed {
+			msgOptions.headers = maps.Clone(options.headers)
+			if msgOptions.headers == nil {
+				msgOptions.headers = make(map[string]string, 1)
+			}
+			msgOptions.headers[HeaderMessageBodyEncoding] = dto.MessageBodyEncodingGzip
+		}
+
+		locale, err := np.resolveLocale(emailMsg)
+		if err != nil {
+			item.Err = err
+			continue
+		}
+		msgOptions.locale = locale
+		msgOptions.expiresAt = emailMsg.MessageExpiry()
+		if options.idemKey != "" {
+			msgOptions.idemKey = fmt.Sprintf("%s-%d", options.idemKey, i)
+		}
+
+		id := fmt.Sprintf("email-%d-%d", start.UnixNano(), i)
+		if options.messageID != "" {
+			id = fmt.Sprintf("%s-%d", options.messageID, i)
+			if err := validateMessageID(id); err != nil {
+				item.Err = err
+				continue
+			}
+		}
+
+		notificationMsg, err := dto.NewNotificationMessage(id, "email", emailMsg)
+		if err != nil {
+			item.Err = fmt.Errorf("%w: failed to create notification message: %w", ErrFatal, err)
+			continue
+		}
+		notificationMsgs[i] = notificationMsg
+		item.Result.MessageID = notificationMsg.ID
+
+		kafkaMsg, err := np.buildKafkaMessage(notificationMsg, topic, msgOptions)
+		if err != nil {
+			item.Err = err
+			continue
+		}
+		kafkaMsg.Metadata = i
+		kafkaMsgs = append(kafkaMsgs, kafkaMsg)
+	}
+
+	if len(kafkaMsgs) > 0 {
+		np.produceBatchAndWait(ctx, kafkaMsgs, options.acks, result)
+	}
+
+	for i := range result.Items {
+		item := result.Items[i]
+		if item.Dropped {
+			continue
+		}
+		if item.Err == nil && np.logSuccesses {
+			np.logger.Infof("Email message published successfully | ID: %s | Topic: %s | Partition: %d | Offset: %d", item.Result.MessageID, topic, item.Result.Partition, item.Result.Offset)
+		}
+		if np.metrics != nil {
+			np.metrics.RecordPublish("email", topic, time.Since(start), item.Err)
+		}
+		if np.onPublish != nil && notificationMsgs[i] != nil {
+			np.onPublish(*notificationMsgs[i], item.Result, item.Err)
+		}
+	}
+
+	return result, result.Err()
+}
+
+// produceBatchAndWait sends kafkaMsgs in a single produce call and records each
+// message's outcome in result, using the batch index stored in its Metadata. It waits
+// for delivery confirmation, respecting cancellation and the publish deadline of ctx;
+// if ctx is done first, every message is recorded as failed with the error from ctxError.
+func (np *NotificationProducer) produceBatchAndWait(ctx context.Context, kafkaMsgs []*sarama.ProducerMessage, acks sarama.RequiredAcks, result *BatchResult) {
+	done := make(chan error, 1)
+	go func() {
+		done <- np.safeSendMessages(kafkaMsgs, acks)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		np.failAll(kafkaMsgs, ctxError(ctx), result)
+		return
+	}
+
+	var producerErrs sarama.ProducerErrors
+	if err != nil && !errors.As(err, &producerErrs) {
+		np.failAll(kafkaMsgs, fmt.Errorf("failed to produce message: %w", classifyError(err)), result)
+		return
+	}
+
+	failed := make(map[int]bool, len(producerErrs))
+	for _, producerErr := range producerErrs {
+		index := producerErr.Msg.Metadata.(int)
+		failed[index] = true
+		result.Items[index].Err = fmt.Errorf("failed to produce message: %w", classifyError(producerErr.Err))
+	}
+	for _, kafkaMsg := range kafkaMsgs {
+		index := kafkaMsg.Metadata.(int)
+		if !failed[index] {
+			result.Items[index].Result.Partition = kafkaMsg.Partition
+			result.Items[index].Result.Offset = kafkaMsg.Offset
+		}
+	}
+}
+
+// failAll records err as the outcome of every message in kafkaMsgs.
+func (np *NotificationProducer) failAll(kafkaMsgs []*sarama.ProducerMessage, err error, result *BatchResult) {
+	for _, kafkaMsg := range kafkaMsgs {
+		result.Items[kafkaMsg.Metadata.(int)].Err = err
+	}
+}
+
+// safeSendMessages sends the given Kafka messages with the given ack level, tracked
+// as in progress so that Close waits for them instead of closing the producer under
+// them.
+//
+// Returns ErrProducerClosed if the producer is closed, or sarama.ProducerErrors
+// listing the messages that failed.
+func (np *NotificationProducer) safeSendMessages(msgs []*sarama.ProducerMessage, acks sarama.RequiredAcks) error {
+	producer, err := np.beginSend(acks)
+	if err != nil {
+		return err
+	}
+	defer np.sending.Done()
+
+	np.stats.enqueued.Add(int64(len(msgs)))
+	err = producer.SendMessages(msgs)
+	failed := len(msgs)
+	var producerErrs sarama.ProducerErrors
+	if err == nil {
+		failed = 0
+	} else if errors.As(err, &producerErrs) {
+		failed = len(producerErrs)
+	}
+	np.stats.failed.Add(int64(failed))
+	np.stats.delivered.Add(int64(len(msgs) - failed))
+	return err
+}
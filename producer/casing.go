@@ -0,0 +1,96 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// KeyTransform renames a JSON object key, such as CamelCase turning "message_body"
+// into "messageBody".
+type KeyTransform func(key string) string
+
+// WithKeyTransform renames every object key in the payload of messages published to
+// topic with transform, at every level, when the message is encoded, so the same
+// DTOs can serve consumers that expect a different field casing without changing
+// their snake_case struct tags. The configured TopicPrefix is prepended to topic.
+// Only the payload is renamed; the NotificationMessage envelope keeps its fields, so
+// consumers in this library still decode it, and need to decode the payload into
+// types tagged with the transformed names.
+//
+// Keys of maps inside the payload, such as push Data, are renamed too. Keys that
+// transform to the same name collide, and only one of them is kept. Schemas
+// registered with RegisterSchema validate the payload before it is renamed.
+func WithKeyTransform(topic string, transform KeyTransform) Option {
+	return func(np *NotificationProducer) {
+		if np.keyTransforms == nil {
+			np.keyTransforms = make(map[string]KeyTransform)
+		}
+		np.keyTransforms[np.config.Topic(topic)] = transform
+	}
+}
+
+// CamelCase is a KeyTransform that converts snake_case keys to camelCase, e.g.
+// "transaction_details" to "transactionDetails". Keys without underscores are
+// returned unchanged.
+func CamelCase(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+
+	var b strings.Builder
+	b.Grow(len(key))
+	upper := false
+	for i, r := range key {
+		switch {
+		case r == '_' && i > 0:
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// transformKeys re-encodes payload with every object key renamed by transform.
+// Numbers are preserved exactly.
+//
+// Returns payload unchanged if it is not a JSON object or array.
+func transformKeys(payload json.RawMessage, transform KeyTransform) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return json.Marshal(transformValue(value, transform))
+	default:
+		return payload, nil
+	}
+}
+
+// transformValue renames the keys of the objects nested in value with transform.
+func transformValue(value interface{}, transform KeyTransform) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			renamed[transform(key)] = transformValue(field, transform)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = transformValue(item, transform)
+		}
+		return v
+	default:
+		return value
+	}
+}
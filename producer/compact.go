@@ -0,0 +1,51 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CompactPayload encodes the message of a single publish as compactly as JSON allows
+// without changing its content: '<', '>', and '&' are written as is instead of as
+// \u003c-style escapes, other \u escapes in the payload are replaced by the characters
+// they stand for, and insignificant whitespace is removed. This mostly shrinks email
+// payloads carrying HTML bodies.
+//
+// Every key and value is kept, including empty objects and arrays, so consumers that
+// check for key presence see the same payload. Numbers are preserved exactly.
+func CompactPayload() PublishOption {
+	return func(o *publishOptions) {
+		o.compact = true
+	}
+}
+
+// compactPayload re-encodes payload without whitespace, HTML escaping, or other
+// unnecessary \u escapes.
+//
+// Returns an error if payload is not valid JSON.
+func compactPayload(payload json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return encodeJSON(value, false)
+}
+
+// encodeJSON marshals v like json.Marshal, escaping '<', '>', and '&' in strings only
+// if escapeHTML is set.
+func encodeJSON(v interface{}, escapeHTML bool) ([]byte, error) {
+	if escapeHTML {
+		return json.Marshal(v)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,67 @@
+package producer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// HeaderMessageBodyEncoding is set to "gzip" on email messages whose MessageBody was
+// compressed by WithMessageBodyCompression.
+const HeaderMessageBodyEncoding = "message_body_encoding"
+
+// WithPayloadCompression gzips the value of every message whose marshaled size is at
+// least minBytes, and marks it with a content-encoding: gzip header. Consumers in this
+// library decompress such messages transparently; other consumers must check the
+// header. This is independent of, and in addition to, Kafka's record-batch
+// compression, and pays off for very large individual messages. MaxMessageBytes is
+// checked against the compressed size. A minBytes of zero or less compresses every
+// message.
+func WithPayloadCompression(minBytes int) Option {
+	return func(np *NotificationProducer) {
+		np.compress = true
+		np.compressMinBytes = minBytes
+	}
+}
+
+// WithMessageBodyCompression gzips the MessageBody of every email whose body is at
+// least minBytes long, such as large transactional HTML, using
+// dto.EmailKafkaMessage.CompressMessageBody, and marks the message with a
+// message_body_encoding: gzip header. Consumers get the original body back from
+// ToSendEmailRequest or DecodedMessageBody; uncompressed bodies pass through
+// unchanged. Unlike WithPayloadCompression, the rest of the payload stays readable.
+func WithMessageBodyCompression(minBytes int) Option {
+	return func(np *NotificationProducer) {
+		np.bodyCompress = true
+		np.bodyCompressMinBytes = minBytes
+	}
+}
+
+// compressEmailBody compresses the MessageBody of emailMsg if body compression is
+// enabled and the body reaches the threshold.
+//
+// Returns whether the body was compressed, or an error if compression fails.
+func (np *NotificationProducer) compressEmailBody(emailMsg *dto.EmailKafkaMessage) (bool, error) {
+	if !np.bodyCompress || emailMsg.MessageBodyEncoding != "" || len(emailMsg.MessageBody) < np.bodyCompressMinBytes {
+		return false, nil
+	}
+	if err := emailMsg.CompressMessageBody(); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrFatal, err)
+	}
+	return true, nil
+}
+
+// gzipBytes returns data compressed with gzip.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+package producer
+
+import "context"
+
+// Header keys set from the context by WithTenant, WithSource, and WithSchemaVersion.
+const (
+	HeaderTenant        = "tenant"
+	HeaderSource        = "source"
+	HeaderSchemaVersion = "schema_version"
+)
+
+type contextHeadersKey struct{}
+
+// WithTenant returns a copy of ctx that makes every publish using it carry a tenant
+// header with the given tenant ID.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return WithContextHeader(ctx, HeaderTenant, tenantID)
+}
+
+// WithSource returns a copy of ctx that makes every publish using it carry a source
+// header naming the publishing service.
+func WithSource(ctx context.Context, service string) context.Context {
+	return WithContextHeader(ctx, HeaderSource, service)
+}
+
+// WithSchemaVersion returns a copy of ctx that makes every publish using it carry a
+// schema_version header of version instead of dto.SchemaVersion. Use a positive
+// integer, bumped whenever the payload's shape changes, so that consumers can select
+// a decoder per version; see consumer.DecoderRegistry.
+func WithSchemaVersion(ctx context.Context, version string) context.Context {
+	return WithContextHeader(ctx, HeaderSchemaVersion, version)
+}
+
+// WithContextHeader returns a copy of ctx that makes every publish using it carry the
+// Kafka header key with value. Headers set per call with WithHeader take precedence.
+func WithContextHeader(ctx context.Context, key, value string) context.Context {
+	parent := contextHeaders(ctx)
+	headers := make(map[string]string, len(parent)+1)
+	for k, v := range parent {
+		headers[k] = v
+	}
+	headers[key] = value
+	return context.WithValue(ctx, contextHeadersKey{}, headers)
+}
+
+// contextHeaders returns the headers stored in ctx, or nil if there are none.
+// The returned map must not be modified.
+func contextHeaders(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(contextHeadersKey{}).(map[string]string)
+	return headers
+}
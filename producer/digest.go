@@ -0,0 +1,199 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// DigestType is the Type of a digest built by DefaultDigest from messages of
+// different types.
+const DigestType = "digest"
+
+// DigestFunc combines the in-app messages buffered for userID, in the order they were
+// added, into the single message published in their place.
+type DigestFunc func(userID string, msgs []dto.InAppKafkaMessage) dto.InAppKafkaMessage
+
+// Digester reduces notification spam by batching low-priority in-app notifications:
+// messages added for a user within a time window are published as a single digest
+// message when the window ends. It is safe for concurrent use.
+type Digester struct {
+	np      *NotificationProducer
+	window  time.Duration
+	combine DigestFunc
+
+	mu      sync.Mutex
+	pending map[string]*digest // Digests waiting for their window to end, by UserID
+	closed  bool
+	timers  sync.WaitGroup // Window timers that have not been stopped or finished
+}
+
+// digest is the buffered messages of one user.
+type digest struct {
+	msgs  []dto.InAppKafkaMessage
+	timer *time.Timer
+}
+
+// NewDigester creates a Digester that publishes through np, collecting the messages
+// of each user for window after the first one arrives. combine builds the digest; a
+// nil combine uses DefaultDigest. A window that ends alone with a single message
+// publishes it unchanged.
+func NewDigester(np *NotificationProducer, window time.Duration, combine DigestFunc) *Digester {
+	if combine == nil {
+		combine = DefaultDigest
+	}
+	return &Digester{
+		np:      np,
+		window:  window,
+		combine: combine,
+		pending: make(map[string]*digest),
+	}
+}
+
+// Add buffers inAppMsg for its user, starting the user's window if none is open.
+// Digests whose window ends are published in the background with the producer's
+// publish timeout, and publish errors are logged.
+//
+// Returns ErrProducerClosed if the Digester is closed, or an error wrapping ErrFatal
+// if inAppMsg has no UserID.
+func (d *Digester) Add(inAppMsg dto.InAppKafkaMessage) error {
+	if inAppMsg.UserID == "" {
+		return fmt.Errorf("%w: in-app message has no user ID", ErrFatal)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrProducerClosed
+	}
+	if pending, ok := d.pending[inAppMsg.UserID]; ok {
+		pending.msgs = append(pending.msgs, inAppMsg)
+		return nil
+	}
+
+	pending := &digest{msgs: []dto.InAppKafkaMessage{inAppMsg}}
+	d.timers.Add(1)
+	pending.timer = time.AfterFunc(d.window, func() {
+		defer d.timers.Done()
+		d.windowEnded(inAppMsg.UserID, pending)
+	})
+	d.pending[inAppMsg.UserID] = pending
+	return nil
+}
+
+// windowEnded publishes the digest of userID, unless it was already taken by Flush.
+func (d *Digester) windowEnded(userID string, pending *digest) {
+	d.mu.Lock()
+	if d.pending[userID] != pending {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, userID)
+	d.mu.Unlock()
+
+	if err := d.publish(context.Background(), userID, pending); err != nil {
+		d.np.logger.Errorf("Failed to publish digest | User: %s | Messages: %d | Error: %v", userID, len(pending.msgs), err)
+	}
+}
+
+// Flush publishes every buffered digest now, without waiting for its window to end.
+//
+// Returns the publish errors, joined, if any.
+func (d *Digester) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	pending := d.takeAll()
+	d.mu.Unlock()
+
+	var errs []error
+	for userID, p := range pending {
+		if err := d.publish(ctx, userID, p); err != nil {
+			errs = append(errs, fmt.Errorf("failed to publish digest for user %s: %w", userID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops accepting messages, publishes every buffered digest, and waits for
+// digests already being published in the background. Close the Digester before its
+// producer, so that the flush can still publish. It is safe to call multiple times;
+// subsequent calls have no effect and return nil.
+//
+// Returns the publish errors, joined, if any.
+func (d *Digester) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	err := d.Flush(ctx)
+	d.timers.Wait()
+	return err
+}
+
+// takeAll removes and returns every buffered digest, stopping their window timers.
+// It must be called with d.mu held.
+func (d *Digester) takeAll() map[string]*digest {
+	pending := d.pending
+	d.pending = make(map[string]*digest)
+	for _, p := range pending {
+		if p.timer.Stop() {
+			d.timers.Done()
+		}
+	}
+	return pending
+}
+
+// publish publishes the digest of the messages in pending, or the message itself if
+// there is only one.
+func (d *Digester) publish(ctx context.Context, userID string, pending *digest) error {
+	inAppMsg := pending.msgs[0]
+	if len(pending.msgs) > 1 {
+		inAppMsg = d.combine(userID, pending.msgs)
+	}
+	return d.np.PublishInAppMessage(ctx, inAppMsg)
+}
+
+// DefaultDigest combines msgs into one message for userID: the titles joined with
+// "; ", the bodies joined by newlines, and the original messages listed under the
+// "digest" key of Data. The digest keeps the common Type and Locale of msgs, or
+// DigestType and no locale if they differ, takes the highest Priority, and expires
+// with the last of msgs to expire, or never if any of them does not expire.
+func DefaultDigest(userID string, msgs []dto.InAppKafkaMessage) dto.InAppKafkaMessage {
+	titles := make([]string, 0, len(msgs))
+	bodies := make([]string, 0, len(msgs))
+	combined := dto.InAppKafkaMessage{
+		UserID:    userID,
+		Type:      msgs[0].Type,
+		Locale:    msgs[0].Locale,
+		ExpiresAt: msgs[0].ExpiresAt,
+		Data:      map[string]interface{}{"digest": msgs},
+	}
+	for _, msg := range msgs {
+		titles = append(titles, msg.Title)
+		bodies = append(bodies, msg.Message)
+		if msg.Type != combined.Type {
+			combined.Type = DigestType
+		}
+		if msg.Locale != combined.Locale {
+			combined.Locale = ""
+		}
+		combined.Priority = max(combined.Priority, msg.Priority)
+		if msg.ExpiresAt == nil {
+			combined.ExpiresAt = nil
+		} else if combined.ExpiresAt != nil && msg.ExpiresAt.After(*combined.ExpiresAt) {
+			combined.ExpiresAt = msg.ExpiresAt
+		}
+	}
+	combined.Title = strings.Join(titles, "; ")
+	combined.Message = strings.Join(bodies, "\n")
+	return combined
+}
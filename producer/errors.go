@@ -0,0 +1,111 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	// ErrRetriable marks a publish failure that may succeed if retried, such as a
+	// leader election or a transient network error.
+	ErrRetriable = errors.New("retriable publish error")
+
+	// ErrFatal marks a publish failure that will not succeed if retried unchanged,
+	// such as an oversized message or an authorization failure.
+	ErrFatal = errors.New("fatal publish error")
+
+	// ErrTimeout is returned when delivery is not confirmed within the publish timeout.
+	ErrTimeout = errors.New("timeout while waiting for message delivery")
+
+	// ErrDeadlineExceeded is returned when the caller's context deadline passes before a
+	// publish completes. It also matches context.DeadlineExceeded.
+	ErrDeadlineExceeded = errors.New("publish deadline exceeded")
+
+	// ErrMessageTooLarge is returned when a marshaled message exceeds the configured MaxMessageBytes.
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrProducerClosed is returned when publishing on a producer that has been closed.
+	ErrProducerClosed = errors.New("producer is closed")
+
+	// ErrQueueFull is returned, wrapped with ErrRetriable, when a fire-and-forget
+	// publish finds the queue full under QueueError. It is also the Err of the
+	// DeliveryReport of a message discarded under QueueDropOldest.
+	ErrQueueFull = errors.New("fire-and-forget queue is full")
+
+	// ErrRateLimited is returned when a message type exceeds its configured RateLimit.
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrInvalidMessageID is returned when a message ID set with WithMessageID is not
+	// in the accepted format.
+	ErrInvalidMessageID = errors.New("invalid message ID")
+)
+
+// retriableKErrors lists the broker error codes that Kafka documents as retriable.
+var retriableKErrors = map[sarama.KError]bool{
+	sarama.ErrUnknownTopicOrPartition:         true,
+	sarama.ErrLeaderNotAvailable:              true,
+	sarama.ErrNotLeaderForPartition:           true,
+	sarama.ErrRequestTimedOut:                 true,
+	sarama.ErrBrokerNotAvailable:              true,
+	sarama.ErrReplicaNotAvailable:             true,
+	sarama.ErrNetworkException:                true,
+	sarama.ErrOffsetsLoadInProgress:           true,
+	sarama.ErrConsumerCoordinatorNotAvailable: true,
+	sarama.ErrNotCoordinatorForConsumer:       true,
+	sarama.ErrNotEnoughReplicas:               true,
+	sarama.ErrNotEnoughReplicasAfterAppend:    true,
+	sarama.ErrKafkaStorageError:               true,
+	sarama.ErrFencedLeaderEpoch:               true,
+	sarama.ErrUnknownLeaderEpoch:              true,
+	sarama.ErrOffsetNotAvailable:              true,
+	sarama.ErrPreferredLeaderNotAvailable:     true,
+	sarama.ErrThrottlingQuotaExceeded:         true,
+}
+
+// IsRetriable reports whether err was classified as retriable by the producer.
+func IsRetriable(err error) bool {
+	return errors.Is(err, ErrRetriable) || errors.Is(err, ErrTimeout)
+}
+
+// classifyError wraps a Sarama send error with ErrRetriable or ErrFatal so callers
+// can decide whether to retry with errors.Is. The original error stays in the chain.
+func classifyError(err error) error {
+	if isRetriableSendError(err) {
+		return fmt.Errorf("%w: %w", ErrRetriable, err)
+	}
+	return fmt.Errorf("%w: %w", ErrFatal, err)
+}
+
+// isRetriableSendError reports whether a Sarama send error is transient.
+func isRetriableSendError(err error) bool {
+	var kerr sarama.KError
+	if errors.As(err, &kerr) {
+		return retriableKErrors[kerr]
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, sarama.ErrOutOfBrokers) ||
+		errors.Is(err, sarama.ErrNotConnected) ||
+		errors.Is(err, sarama.ErrShuttingDown)
+}
+
+// ctxError returns the error for a publish whose ctx is done: ErrTimeout if the
+// producer's publish timeout fired, an error wrapping ErrDeadlineExceeded and
+// context.DeadlineExceeded if the caller's deadline passed, or the cancellation error.
+func ctxError(ctx context.Context) error {
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return ErrTimeout
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrDeadlineExceeded, ctx.Err())
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,30 @@
+package producer
+
+import (
+	"context"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// Producer is the publishing API of NotificationProducer. Depend on it instead of
+// *NotificationProducer so that tests can substitute a fake that needs no Kafka
+// brokers.
+type Producer interface {
+	PublishSMSMessage(ctx context.Context, smsMsg dto.SMSKafkaMessage, opts ...PublishOption) error
+	PublishEmailMessage(ctx context.Context, emailMsg dto.EmailKafkaMessage, opts ...PublishOption) error
+	PublishSendEmailRequest(ctx context.Context, req dto.SendEmailRequest, opts ...PublishOption) error
+	PublishInAppMessage(ctx context.Context, inAppMsg dto.InAppKafkaMessage, opts ...PublishOption) error
+	PublishPushMessage(ctx context.Context, pushMsg dto.PushKafkaMessage, opts ...PublishOption) error
+	PublishToTopic(ctx context.Context, topic string, msgType string, payload interface{}, opts ...PublishOption) error
+	PublishMessage(ctx context.Context, payload interface{}, msgType, topic, logType string, opts ...PublishOption) error
+	PublishEmailBatch(ctx context.Context, emailMsgs []dto.EmailKafkaMessage, opts ...PublishOption) (*BatchResult, error)
+	PublishMulti(ctx context.Context, items []Publishable) ([]BatchItemResult, error)
+	PublishTombstone(ctx context.Context, topic, key string) error
+
+	Ping(ctx context.Context) error
+	Stats() Stats
+	Close() error
+	CloseContext(ctx context.Context) (int, error)
+}
+
+var _ Producer = (*NotificationProducer)(nil)
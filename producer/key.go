@@ -0,0 +1,36 @@
+package producer
+
+// KeyFunc derives the Kafka message key from a payload, so that related messages
+// (e.g., all in-app notifications of one user) land on the same partition and are
+// consumed in order. Returning false leaves the message keyless, so it is partitioned
+// randomly.
+type KeyFunc func(payload interface{}) (string, bool)
+
+// WithKeyFunc registers fn to derive the message key of every message of msgType,
+// replacing any KeyFunc previously registered for it. For example, to keep each
+// user's in-app notifications in order:
+//
+//	producer.WithKeyFunc("in_app", func(payload interface{}) (string, bool) {
+//		msg, ok := payload.(dto.InAppKafkaMessage)
+//		return msg.UserID, ok && msg.UserID != ""
+//	})
+//
+// A key set with WithIdempotencyKey takes precedence over the derived key.
+func WithKeyFunc(msgType string, fn KeyFunc) Option {
+	return func(np *NotificationProducer) {
+		if np.keyFuncs == nil {
+			np.keyFuncs = make(map[string]KeyFunc)
+		}
+		np.keyFuncs[msgType] = fn
+	}
+}
+
+// messageKey returns the key derived from payload by the KeyFunc registered for
+// msgType, or false if there is none or it declines to key the payload.
+func (np *NotificationProducer) messageKey(msgType string, payload interface{}) (string, bool) {
+	fn, ok := np.keyFuncs[msgType]
+	if !ok {
+		return "", false
+	}
+	return fn(payload)
+}
@@ -0,0 +1,36 @@
+package producer
+
+import "gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
+
+// Logger is the subset of utils.Logger used by the notification services.
+// Any utils.Logger satisfies it.
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// nopLogger discards all log output. It is used when no logger is supplied.
+type nopLogger struct{}
+
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// errorsOnlyLogger discards Info output and forwards errors to the wrapped Logger.
+type errorsOnlyLogger struct {
+	Logger
+}
+
+func (errorsOnlyLogger) Infof(string, ...interface{}) {}
+
+// NopLogger returns a Logger that discards all output.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// loggerOrNop returns logger as a Logger, or a no-op logger if it is nil.
+func loggerOrNop(logger utils.Logger) Logger {
+	if logger == nil {
+		return nopLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,131 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// Publishable is a single message for PublishMulti. Use the SMS, Email, InApp, and
+// Push constructors for the typed messages.
+type Publishable struct {
+	MsgType string          // Message type, e.g. "email"
+	Topic   string          // Topic to publish to; the configured TopicPrefix is prepended
+	Payload interface{}     // Message payload
+	Options []PublishOption // Options for this message only
+}
+
+// SMS returns a Publishable for smsMsg on the configured SMS topic.
+func SMS(smsMsg dto.SMSKafkaMessage, opts ...PublishOption) Publishable {
+	return Publishable{MsgType: "sms", Payload: smsMsg, Options: opts}
+}
+
+// Email returns a Publishable for emailMsg on the configured email topic.
+func Email(emailMsg dto.EmailKafkaMessage, opts ...PublishOption) Publishable {
+	return Publishable{MsgType: "email", Payload: emailMsg, Options: opts}
+}
+
+// InApp returns a Publishable for inAppMsg on the configured in-app topic.
+func InApp(inAppMsg dto.InAppKafkaMessage, opts ...PublishOption) Publishable {
+	return Publishable{MsgType: "in_app", Payload: inAppMsg, Options: opts}
+}
+
+// Push returns a Publishable for pushMsg on the configured push topic.
+func Push(pushMsg dto.PushKafkaMessage, opts ...PublishOption) Publishable {
+	return Publishable{MsgType: "push", Payload: pushMsg, Options: opts}
+}
+
+// topicFor returns the configured topic for the typed message types, or "" for
+// any other type.
+func (np *NotificationProducer) topicFor(msgType string) string {
+	switch msgType {
+	case "sms":
+		return np.config.SMSTopic
+	case "email":
+		return np.config.EmailTopic
+	case "in_app":
+		return np.config.InAppTopic
+	case "push":
+		return np.config.PushTopic
+	default:
+		return ""
+	}
+}
+
+// PublishMulti publishes every item concurrently on a best-effort basis, for example
+// the email and in-app messages triggered by one business event. A failure of one
+// item does not stop or undo the others. Items without a Topic are published to the
+// configured topic for their MsgType. Typed payloads are checked and transformed as
+// by their typed helper, such as PublishEmailMessage, so an item is accepted and
+// shaped here exactly as it would be there; with PerRecipient, the Result of an email
+// is that of its first recipient's message.
+//
+// Returns the per-item results in input order, together with an error naming the
+// channels that succeeded and wrapping each failure if any item failed.
+func (np *NotificationProducer) PublishMulti(ctx context.Context, items []Publishable) ([]BatchItemResult, error) {
+	results := make([]BatchItemResult, len(items))
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		results[i].Index = i
+		topic := item.Topic
+		if topic == "" {
+			topic = np.topicFor(item.MsgType)
+		}
+		if topic == "" {
+			results[i].Result = PublishResult{Partition: -1, Offset: -1}
+			results[i].Err = fmt.Errorf("no topic configured for message type %q", item.MsgType)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, item Publishable, topic string) {
+			defer wg.Done()
+			results[i].Result, results[i].Err = np.publishItem(ctx, item, topic)
+		}(i, item, topic)
+	}
+	wg.Wait()
+
+	var succeeded []string
+	var errs []error
+	for i, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", items[i].MsgType, result.Err))
+			continue
+		}
+		succeeded = append(succeeded, items[i].MsgType)
+	}
+	if len(errs) == 0 {
+		return results, nil
+	}
+	return results, fmt.Errorf("%d of %d messages failed (succeeded: [%s]): %w", len(errs), len(items), strings.Join(succeeded, ", "), errors.Join(errs...))
+}
+
+// publishItem publishes a PublishMulti item to topic the way its typed helper does:
+// an empty typed payload is rejected, and an email is prepared with prepareEmail and
+// fanned out with PerRecipient like PublishEmailMessage.
+//
+// Returns where the message was written, and any error from the checks or from
+// publishing.
+func (np *NotificationProducer) publishItem(ctx context.Context, item Publishable, topic string) (PublishResult, error) {
+	switch payload := item.Payload.(type) {
+	case dto.EmailKafkaMessage:
+		opts, err := np.prepareEmail(&payload, item.Options)
+		if err != nil {
+			return PublishResult{Topic: np.config.Topic(topic), Partition: -1, Offset: -1}, err
+		}
+		if len(payload.Recipients) > 1 && newPublishOptions(opts).perRecipient {
+			return np.publishPerRecipient(ctx, payload, topic, opts)
+		}
+		return np.publish(ctx, payload, item.MsgType, topic, item.MsgType, opts)
+	case dto.SMSKafkaMessage, dto.InAppKafkaMessage, dto.PushKafkaMessage:
+		if err := requirePayload(payload); err != nil {
+			return PublishResult{Topic: np.config.Topic(topic), Partition: -1, Offset: -1}, err
+		}
+	}
+	return np.publish(ctx, item.Payload, item.MsgType, topic, item.MsgType, item.Options)
+}
@@ -0,0 +1,232 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Option configures a NotificationProducer at construction time.
+type Option func(*NotificationProducer)
+
+// PublishResult describes where a published message was written.
+// Partition and Offset are -1 if the message was not delivered.
+type PublishResult struct {
+	MessageID string
+	Topic     string
+	Partition int32
+	Offset    int64
+	Buffered  bool // Whether the message was buffered for redelivery by WithStoreAndForward
+}
+
+// OnPublishFunc is invoked after every publish attempt with the message, its
+// delivery result, and the error, if any.
+type OnPublishFunc func(msg dto.NotificationMessage, result PublishResult, err error)
+
+// WithOnPublish registers a callback invoked after each publish, whether it succeeds
+// or fails. It runs synchronously on the publishing goroutine, so it should be fast;
+// use it for auditing, metrics, or other side effects.
+func WithOnPublish(fn OnPublishFunc) Option {
+	return func(np *NotificationProducer) {
+		np.onPublish = fn
+	}
+}
+
+// WithMetrics reports the outcome and latency of every publish attempt to recorder.
+func WithMetrics(recorder metrics.Recorder) Option {
+	return func(np *NotificationProducer) {
+		np.metrics = recorder
+	}
+}
+
+// WithLogSuccesses controls whether every successful publish is logged at Info.
+// It defaults to true; disable it on high-volume topics to keep logs to errors and
+// lifecycle events. Failures are always logged or returned.
+func WithLogSuccesses(enabled bool) Option {
+	return func(np *NotificationProducer) {
+		np.logSuccesses = enabled
+	}
+}
+
+// Silent suppresses all Info logging by the producer, including per-publish success
+// lines and lifecycle messages such as the close message, while still logging errors.
+// Unlike log levels it works with any Logger, which suits serverless deployments that
+// pay for log volume. It implies WithLogSuccesses(false).
+func Silent() Option {
+	return func(np *NotificationProducer) {
+		np.logger = errorsOnlyLogger{np.logger}
+		np.logSuccesses = false
+	}
+}
+
+// defaultPublishTimeout bounds a single publish when WithPublishTimeout is not used.
+const defaultPublishTimeout = 30 * time.Second
+
+// WithPublishTimeout bounds the total time a single publish may take, including
+// Sarama's internal retries, to d; it defaults to 30 seconds. Exceeding it returns
+// ErrTimeout. A shorter context deadline still applies and returns ErrDeadlineExceeded.
+func WithPublishTimeout(d time.Duration) Option {
+	return func(np *NotificationProducer) {
+		if d > 0 {
+			np.publishTimeout = d
+		}
+	}
+}
+
+// withPublishTimeout returns a copy of ctx that is cancelled with cause ErrTimeout
+// once the publish timeout elapses.
+func (np *NotificationProducer) withPublishTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, np.publishTimeout, ErrTimeout)
+}
+
+// PublishOption configures how a single message is published.
+type PublishOption func(*publishOptions)
+
+// publishOptions holds the per-call settings applied by PublishOption values.
+type publishOptions struct {
+	acks      sarama.RequiredAcks
+	locale    string
+	expiresAt *time.Time        // Expiry of the payload, written to the expires_at header
+	headers   map[string]string // Custom headers set per call, merged over context headers
+	compact   bool              // Whether the message is encoded without HTML and other needless escapes
+	idemKey   string            // Caller-defined idempotency key, written as a header and the message key
+	messageID string            // Caller-defined message ID used instead of a generated one
+
+	perRecipient bool                   // Whether a multi-recipient email is published as one message per recipient
+	metadata     map[string]interface{} // Entries added to the NotificationMessage Headers
+}
+
+// newPublishOptions applies opts on top of the producer defaults.
+func newPublishOptions(opts []PublishOption) publishOptions {
+	options := publishOptions{
+		acks: sarama.WaitForAll,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// withContextHeaders merges the headers stored in ctx, and the trace context of ctx
+// as written by the global OpenTelemetry propagator (e.g. traceparent), under the
+// per-call headers, so that headers set with WithHeader override context-derived ones.
+// Consumers created with WithTracer continue the trace from these headers.
+func (o *publishOptions) withContextHeaders(ctx context.Context) {
+	fromContext := contextHeaders(ctx)
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+	if len(fromContext) == 0 && len(traceHeaders) == 0 {
+		return
+	}
+
+	headers := make(map[string]string, len(fromContext)+len(traceHeaders)+len(o.headers))
+	for key, value := range fromContext {
+		headers[key] = value
+	}
+	for key, value := range traceHeaders {
+		headers[key] = value
+	}
+	for key, value := range o.headers {
+		headers[key] = value
+	}
+	o.headers = headers
+}
+
+// WithRequiredAcks sets the broker acknowledgement level for a single publish.
+//
+// sarama.WaitForAll (the default) waits for every in-sync replica and is the right
+// choice for OTPs and transactional messages. sarama.WaitForLocal only waits for the
+// partition leader, trading a small risk of loss on leader failure for lower latency,
+// which suits low-value notifications such as in-app pings. sarama.NoResponse does
+// not wait for the broker at all.
+//
+// Each non-default ack level is served by its own underlying Sarama producer, created
+// lazily on first use, so mixing ack levels costs one extra set of broker connections
+// per level.
+func WithRequiredAcks(acks sarama.RequiredAcks) PublishOption {
+	return func(o *publishOptions) {
+		o.acks = acks
+	}
+}
+
+// WithHeader sets a custom Kafka header on a single publish, overriding any value for
+// key set on the context with WithContextHeader. The standard message_id, type,
+// timestamp, locale, expires_at, content-encoding, idempotency_key, encrypted, and
+// time_format headers cannot be overridden.
+func WithHeader(key, value string) PublishOption {
+	return func(o *publishOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string)
+		}
+		o.headers[key] = value
+	}
+}
+
+// WithMetadata sets key to value in the Headers of the published NotificationMessage,
+// so it travels in the JSON envelope, and also writes it as a Kafka header so that
+// consumers can read it without decoding the message. Non-string values are written
+// to the Kafka header as JSON. A header with the same key set with WithHeader or on
+// the context takes precedence in the Kafka headers, and the standard headers
+// cannot be overridden.
+func WithMetadata(key string, value interface{}) PublishOption {
+	return func(o *publishOptions) {
+		if o.metadata == nil {
+			o.metadata = make(map[string]interface{})
+		}
+		o.metadata[key] = value
+	}
+}
+
+// WithIdempotencyKey attaches a caller-defined business identity, such as
+// "otp-for-txn-12345", to a single publish. It is written to the idempotency_key
+// header, and consumers with deduplication enabled run their handler at most once per
+// key within the dedup TTL, even if the message is published again under a new ID.
+//
+// The key is also used as the Kafka message key, so every message with the same key
+// lands on the same partition and is handled in order by a single consumer. Messages
+// in a batch get key suffixed with "-" and their index, and messages published with
+// PerRecipient get it suffixed with ":" and the recipient's address.
+func WithIdempotencyKey(key string) PublishOption {
+	return func(o *publishOptions) {
+		o.idemKey = key
+	}
+}
+
+// maxMessageIDLength is the longest message ID accepted by WithMessageID.
+const maxMessageIDLength = 128
+
+// messageIDPattern matches the message IDs accepted by WithMessageID.
+var messageIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:@+-]*$`)
+
+// WithMessageID publishes the message under id, such as a transaction reference,
+// instead of a generated ID, so the message ID carries business meaning and can be
+// traced end to end. The caller is responsible for its uniqueness: consumers with
+// deduplication enabled skip a message whose ID they have already processed.
+//
+// id must be at most 128 characters of letters, digits, '.', '_', ':', '@', '+', and
+// '-', and start with a letter or digit; otherwise the publish fails with ErrInvalidMessageID.
+// Messages in a batch get id suffixed with "-" and their index, and messages
+// published with PerRecipient get it suffixed with ":" and the recipient's address.
+func WithMessageID(id string) PublishOption {
+	return func(o *publishOptions) {
+		o.messageID = id
+	}
+}
+
+// validateMessageID checks id against the format accepted by WithMessageID.
+//
+// Returns ErrInvalidMessageID, wrapped with ErrFatal, if id is malformed.
+func validateMessageID(id string) error {
+	if len(id) > maxMessageIDLength || !messageIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %w: %q must be at most %d letters, digits, '.', '_', ':', '@', '+', or '-' starting with a letter or digit",
+			ErrFatal, ErrInvalidMessageID, id, maxMessageIDLength)
+	}
+	return nil
+}
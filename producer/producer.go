@@ -2,176 +2,820 @@ package producer
 
 import (
 	"context"
+	"crypto/cipher"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/dawit-go/notification-kafka-lib/config"
 	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+	"github.com/dawit-go/notification-kafka-lib/metrics"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"gitlab.com/bersufekadgetachew/cbe-super-app-shared/shared/utils"
 )
 
+// reservedHeaders are the standard headers written by the producer, which custom
+// headers cannot override.
+var reservedHeaders = map[string]bool{
+	"message_id":       true,
+	"type":             true,
+	"timestamp":        true,
+	"locale":           true,
+	"expires_at":       true,
+	"content-encoding": true,
+	"idempotency_key":  true,
+	HeaderEncrypted:    true,
+	HeaderTimeFormat:   true,
+}
+
 // NotificationProducer wraps a Sarama SyncProducer to publish notification messages
 // to Kafka topics. It supports configuration, graceful close, and synchronous delivery confirmation.
 type NotificationProducer struct {
-	producer sarama.SyncProducer
-	logger   utils.Logger
-	config   config.KafkaConfig
-	mu       sync.Mutex
-	closed   bool
+	client    sarama.Client
+	producer  sarama.SyncProducer
+	producers map[sarama.RequiredAcks]sarama.SyncProducer // Lazily created producers for non-default ack levels
+	brokers   []string
+	logger    Logger
+	config    config.KafkaConfig
+	mu        sync.Mutex
+	closed    bool
+	sending   sync.WaitGroup                // Sends and fire-and-forget enqueues in progress, which Close waits for
+	schemas   map[string]*jsonschema.Schema // JSON schemas registered per message type
+	schemaMu  sync.RWMutex
+	onPublish OnPublishFunc    // Optional callback invoked after every publish attempt
+	metrics   metrics.Recorder // Optional recorder for publish metrics
+
+	fireAndForget bool                         // Whether publishes are enqueued without delivery confirmation
+	async         sarama.AsyncProducer         // Producer used in fire-and-forget mode
+	closing       chan struct{}                // Closed by Close to abort enqueues waiting for queue room
+	queue         chan *sarama.ProducerMessage // Fire-and-forget messages waiting for the AsyncProducer
+	queuePolicy   QueuePolicy                  // What a publish does when queue is full
+	queueStop     chan struct{}                // Closed by Close to have the queue emptied
+	queueDone     chan struct{}                // Closed once the queue has been emptied
+	reports       chan DeliveryReport          // Optional fire-and-forget delivery outcomes
+	reportBuffer  int                          // Capacity of reports; zero disables delivery reports
+
+	rateLimits      map[string]*typeLimiter // Optional publish rate limits keyed by message type
+	keyFuncs        map[string]KeyFunc      // Optional message key derivation keyed by message type
+	keyTransforms   map[string]KeyTransform // Optional payload key renaming keyed by topic
+	compactedTopics map[string]bool         // Topics on which PublishTombstone may clear keys
+	buffer          *forwardBuffer          // Optional store-and-forward buffer of failed messages
+
+	encrypt       bool            // Whether payloads are encrypted
+	encryptTypes  map[string]bool // Message types whose payloads are encrypted; empty means all
+	payloadCipher cipher.AEAD     // Cipher for payload encryption, set when encrypt is true
+
+	timeFormat TimeFormat // Encoding of times in message JSON
+
+	compress         bool // Whether large message values are gzipped
+	compressMinBytes int  // Minimum marshaled size for a value to be gzipped
+
+	bodyCompress         bool // Whether large email bodies are gzipped
+	bodyCompressMinBytes int  // Minimum email body length to be gzipped
+
+	stats          producerStats // Delivery counters reported by Stats
+	logSuccesses   bool          // Whether every successful publish is logged at Info
+	publishTimeout time.Duration // Upper bound on a single publish, including retries
 }
 
 // NewNotificationProducer creates a new NotificationProducer instance using the
 // provided KafkaConfig and logger. It configures the Sarama producer with
-// specified brokers, SASL auth, and producer options.
+// specified brokers, SASL auth, and producer options. A nil logger is replaced
+// with a no-op logger. Options customize producer-wide behavior.
+//
+// A config in which two channels share a topic is logged as a misconfiguration; see
+// config.KafkaConfig.ValidateTopics.
 //
-// Returns an error if the brokers list is empty or if the producer fails to initialize.
-func NewNotificationProducer(cfg config.KafkaConfig, logger utils.Logger) (*NotificationProducer, error) {
-	if cfg.Brokers == "" {
-		return nil, fmt.Errorf("Kafka brokers not configured")
+// Returns an error if the brokers list is empty, the SASL settings are incomplete or
+// use an unsupported mechanism, or the producer fails to initialize.
+func NewNotificationProducer(cfg config.KafkaConfig, logger utils.Logger, opts ...Option) (*NotificationProducer, error) {
+	if err := cfg.ValidateSASL(); err != nil {
+		return nil, fmt.Errorf("invalid Kafka config: %w", err)
 	}
 
-	brokers := strings.Split(cfg.Brokers, ",")
-	for i, broker := range brokers {
-		brokers[i] = strings.TrimSpace(broker)
+	brokers, err := kafkautil.Brokers(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	kafkaConfig := sarama.NewConfig()
-	kafkaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	client, err := sarama.NewClient(brokers, newProducerConfig(cfg, sarama.WaitForAll))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	np := &NotificationProducer{
+		client:         client,
+		producer:       producer,
+		producers:      make(map[sarama.RequiredAcks]sarama.SyncProducer),
+		brokers:        brokers,
+		logger:         loggerOrNop(logger),
+		config:         cfg,
+		logSuccesses:   true,
+		publishTimeout: defaultPublishTimeout,
+	}
+	for _, opt := range opts {
+		opt(np)
+	}
+	if err := cfg.ValidateTopics(); err != nil {
+		np.logger.Errorf("Kafka topic misconfiguration, messages of several channels share a topic: %v", err)
+	}
+
+	if err := np.initEncryption(); err != nil {
+		_ = producer.Close()
+		_ = client.Close()
+		return nil, err
+	}
+
+	if np.fireAndForget {
+		if err := np.startAsync(); err != nil {
+			_ = producer.Close()
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	if np.buffer != nil {
+		go np.forward()
+	}
+
+	return np, nil
+}
+
+// newProducerConfig builds the Sarama producer configuration for cfg with the given ack level.
+func newProducerConfig(cfg config.KafkaConfig, acks sarama.RequiredAcks) *sarama.Config {
+	kafkaConfig := kafkautil.NewSaramaConfig(cfg)
+	kafkaConfig.Producer.RequiredAcks = acks
 	kafkaConfig.Producer.Retry.Max = 3
 	kafkaConfig.Producer.Return.Successes = true
 	kafkaConfig.Producer.Compression = sarama.CompressionSnappy
 	kafkaConfig.Producer.Flush.Frequency = 500 * time.Millisecond
-	kafkaConfig.Producer.Partitioner = sarama.NewRandomPartitioner
-	kafkaConfig.Version = sarama.V2_6_0_0
-
-	if cfg.SASLEnabled {
-		kafkaConfig.Net.SASL.Enable = true
-		kafkaConfig.Net.SASL.User = cfg.SASLUsername
-		kafkaConfig.Net.SASL.Password = cfg.SASLPassword
-		kafkaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(cfg.SASLMechanism)
+	kafkaConfig.Producer.Partitioner = sarama.NewHashPartitioner // Keyless messages are still partitioned randomly
+	if cfg.MaxMessageBytes > 0 {
+		kafkaConfig.Producer.MaxMessageBytes = cfg.MaxMessageBytes
 	}
-
-	producer, err := sarama.NewSyncProducer(brokers, kafkaConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	if cfg.ChannelBufferSize > 0 {
+		kafkaConfig.ChannelBufferSize = cfg.ChannelBufferSize
 	}
+	return kafkaConfig
+}
 
-	return &NotificationProducer{
-		producer: producer,
-		logger:   logger,
-		config:   cfg,
-	}, nil
+// Close gracefully closes the Kafka producer, flushing buffered messages and
+// releasing all resources. It is safe to call multiple times; subsequent calls
+// have no effect and return nil.
+//
+// Returns an error if any underlying producer failed to close cleanly, which may
+// mean buffered messages were not delivered.
+func (np *NotificationProducer) Close() error {
+	_, err := np.CloseContext(context.Background())
+	return err
 }
 
-// Close gracefully closes the Kafka producer, releasing all resources.
-// It is safe to call multiple times; subsequent calls have no effect.
-func (np *NotificationProducer) Close() {
+// CloseContext closes the producer like Close, but bounds the flush of the
+// fire-and-forget buffer by ctx, so shutdown completes within a deadline such as the
+// Kubernetes termination grace period even when Kafka is unreachable. If ctx is done
+// before every enqueued message is delivered or failed, the flush is abandoned and
+// the remaining messages are dropped. Without WithFireAndForget it behaves like Close.
+// Messages still held by WithStoreAndForward are dropped and counted too.
+//
+// Synchronous publishes already handed to Kafka are waited for, up to the publish
+// timeout (see WithPublishTimeout) or until ctx is done, before the underlying
+// producers are closed; publishes still pending then fail with a send error. Publishes
+// started after Close, and fire-and-forget publishes still waiting for room in a full
+// buffer, return ErrProducerClosed.
+//
+// Returns the number of messages dropped, and an error if ctx was done before the
+// flush completed, wrapping ErrDeadlineExceeded or context.Canceled, or if any
+// underlying producer failed to close cleanly.
+func (np *NotificationProducer) CloseContext(ctx context.Context) (int, error) {
 	np.mu.Lock()
-	defer np.mu.Unlock()
-
 	if np.closed {
-		return
+		np.mu.Unlock()
+		return 0, nil
 	}
-
 	np.closed = true
+	np.mu.Unlock()
+
+	// Once closed is set no send or enqueue starts and the producers map no longer
+	// changes, so the lock is not held while waiting for those in progress.
+	if np.closing != nil {
+		close(np.closing)
+	}
+	np.waitSending(ctx)
+
+	var errs []error
+	dropped := 0
+	if np.buffer != nil {
+		if discarded := np.buffer.close(); discarded > 0 {
+			np.stats.failed.Add(int64(discarded))
+			np.logger.Errorf("Discarding undelivered store-and-forward messages | Dropped: %d", discarded)
+			dropped += discarded
+		}
+	}
+	if np.async != nil {
+		abandoned, err := np.closeAsync(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		dropped += abandoned
+	}
+	for acks, producer := range np.producers {
+		if err := producer.Close(); err != nil {
+			np.logger.Errorf("Error closing Kafka producer (acks=%d): %v", acks, err)
+			errs = append(errs, fmt.Errorf("failed to close Kafka producer (acks=%d): %w", acks, err))
+		}
+	}
 	if err := np.producer.Close(); err != nil {
 		np.logger.Errorf("Error closing Kafka producer: %v", err)
+		errs = append(errs, fmt.Errorf("failed to close Kafka producer: %w", err))
 	} else {
 		np.logger.Infof("Kafka producer closed successfully")
 	}
+	if err := np.client.Close(); err != nil {
+		np.logger.Errorf("Error closing Kafka client: %v", err)
+		errs = append(errs, fmt.Errorf("failed to close Kafka client: %w", err))
+	}
+
+	return dropped, errors.Join(errs...)
+}
+
+// waitSending waits for the sends and enqueues in progress to finish, for at most the
+// publish timeout or until ctx is done. It must be called after closed is set, so that
+// no new sends start.
+func (np *NotificationProducer) waitSending(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		np.sending.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(np.publishTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		np.logger.Errorf("Closing Kafka producer with sends still in progress after %s", np.publishTimeout)
+	case <-ctx.Done():
+		np.logger.Errorf("Closing Kafka producer with sends still in progress | Error: %v", ctx.Err())
+	}
 }
 
-// PublishSMSMessage publishes an SMS message to Kafka
-func (np *NotificationProducer) PublishSMSMessage(ctx context.Context, smsMsg dto.SMSKafkaMessage) error {
-	return np.PublishMessage(ctx, smsMsg, "sms", np.config.SMSTopic, "SMS")
+// Ping checks connectivity to the Kafka cluster by refreshing cluster metadata
+// from the brokers, respecting ctx cancellation.
+//
+// Returns ErrProducerClosed if the producer is closed, or an error if no broker
+// responds.
+func (np *NotificationProducer) Ping(ctx context.Context) error {
+	np.mu.Lock()
+	closed := np.closed
+	np.mu.Unlock()
+	if closed {
+		return ErrProducerClosed
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- np.client.RefreshMetadata()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to reach Kafka brokers: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// PublishEmailMessage publishes an email message to Kafka
-func (np *NotificationProducer) PublishEmailMessage(ctx context.Context, emailMsg dto.EmailKafkaMessage) error {
-	return np.PublishMessage(ctx, emailMsg, "email", np.config.EmailTopic, "Email")
+// PublishSMSMessage publishes an SMS message to Kafka. Like the other typed helpers,
+// it rejects a zero-value message with an error wrapping dto.ErrEmptyPayload.
+func (np *NotificationProducer) PublishSMSMessage(ctx context.Context, smsMsg dto.SMSKafkaMessage, opts ...PublishOption) error {
+	if ctx.Err() != nil {
+		return ctxError(ctx)
+	}
+	if err := requirePayload(smsMsg); err != nil {
+		return err
+	}
+	return np.PublishMessage(ctx, smsMsg, "sms", np.config.SMSTopic, "SMS", opts...)
+}
+
+// PublishEmailMessage publishes an email message to Kafka. Its addresses are
+// normalized and deduplicated across Recipients, CC, and BCC first (see
+// dto.EmailKafkaMessage.Dedup), so no address is sent the email twice. With
+// PerRecipient, an email with several recipients is published as one message per
+// recipient.
+//
+// Returns an error wrapping ErrFatal if emailMsg is empty (see dto.ErrEmptyPayload)
+// or fails validation, such as an OTP email without an OTPCode or TransactionDetails
+// that cannot be serialized or are too large, or any error from PublishMessage.
+// Validation errors also wrap the per-field dto.ValidationErrors.
+func (np *NotificationProducer) PublishEmailMessage(ctx context.Context, emailMsg dto.EmailKafkaMessage, opts ...PublishOption) error {
+	if ctx.Err() != nil {
+		return ctxError(ctx)
+	}
+	opts, err := np.prepareEmail(&emailMsg, opts)
+	if err != nil {
+		return err
+	}
+	if len(emailMsg.Recipients) > 1 && newPublishOptions(opts).perRecipient {
+		_, err := np.publishPerRecipient(ctx, emailMsg, np.config.EmailTopic, opts)
+		return err
+	}
+	return np.PublishMessage(ctx, emailMsg, "email", np.config.EmailTopic, "Email", opts...)
+}
+
+// prepareEmail applies the checks and transformations PublishEmailMessage makes
+// before publishing: it rejects an empty emailMsg, deduplicates and validates its
+// addresses, and compresses its body if WithMessageBodyCompression applies, adding the
+// encoding header to opts.
+//
+// Returns the options to publish emailMsg with, or an error wrapping ErrFatal if
+// emailMsg is empty, invalid, or cannot be compressed.
+func (np *NotificationProducer) prepareEmail(emailMsg *dto.EmailKafkaMessage, opts []PublishOption) ([]PublishOption, error) {
+	if err := requirePayload(*emailMsg); err != nil {
+		return nil, err
+	}
+	emailMsg.Dedup()
+	if details := emailMsg.ValidateDetailed(); details != nil {
+		return nil, fmt.Errorf("%w: invalid email message: %w", ErrFatal, details)
+	}
+	compressed, err := np.compressEmailBody(emailMsg)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		opts = append(slices.Clip(opts), WithHeader(HeaderMessageBodyEncoding, dto.MessageBodyEncodingGzip))
+	}
+	return opts, nil
+}
+
+// PublishSendEmailRequest validates req and publishes it to Kafka as an email message.
+//
+// Returns an error wrapping ErrFatal and the per-field dto.ValidationErrors if req
+// fails validation, or any error from PublishEmailMessage.
+func (np *NotificationProducer) PublishSendEmailRequest(ctx context.Context, req dto.SendEmailRequest, opts ...PublishOption) error {
+	if ctx.Err() != nil {
+		return ctxError(ctx)
+	}
+	if details := req.ValidateDetailed(); details != nil {
+		return fmt.Errorf("%w: invalid send email request: %w", ErrFatal, details)
+	}
+	return np.PublishEmailMessage(ctx, req.ToKafkaMessage(), opts...)
 }
 
 // PublishInAppMessage publishes an in-app notification message to Kafka
-func (np *NotificationProducer) PublishInAppMessage(ctx context.Context, inAppMsg dto.InAppKafkaMessage) error {
-	return np.PublishMessage(ctx, inAppMsg, "in_app", np.config.InAppTopic, "In-App Notification")
+func (np *NotificationProducer) PublishInAppMessage(ctx context.Context, inAppMsg dto.InAppKafkaMessage, opts ...PublishOption) error {
+	if ctx.Err() != nil {
+		return ctxError(ctx)
+	}
+	if err := requirePayload(inAppMsg); err != nil {
+		return err
+	}
+	return np.PublishMessage(ctx, inAppMsg, "in_app", np.config.InAppTopic, "In-App Notification", opts...)
 }
 
 // PublishPushMessage publishes a push notification message to Kafka
-func (np *NotificationProducer) PublishPushMessage(ctx context.Context, pushMsg dto.PushKafkaMessage) error {
-	return np.PublishMessage(ctx, pushMsg, "push", np.config.PushTopic, "Push Notification")
+func (np *NotificationProducer) PublishPushMessage(ctx context.Context, pushMsg dto.PushKafkaMessage, opts ...PublishOption) error {
+	if ctx.Err() != nil {
+		return ctxError(ctx)
+	}
+	if err := requirePayload(pushMsg); err != nil {
+		return err
+	}
+	return np.PublishMessage(ctx, pushMsg, "push", np.config.PushTopic, "Push Notification", opts...)
+}
+
+// PublishToTopic publishes a notification message of msgType to the given topic,
+// bypassing the topic configured for that message type. It uses the same message
+// creation, headers, and delivery confirmation as the typed helpers.
+func (np *NotificationProducer) PublishToTopic(ctx context.Context, topic string, msgType string, payload interface{}, opts ...PublishOption) error {
+	if topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	return np.PublishMessage(ctx, payload, msgType, topic, msgType, opts...)
+}
+
+// PublishAndConfirm publishes a notification message of msgType to topic and waits
+// for the broker to acknowledge it at the given ack level. See WithRequiredAcks for
+// the durability tradeoff between ack levels.
+func (np *NotificationProducer) PublishAndConfirm(ctx context.Context, topic string, msgType string, payload interface{}, acks sarama.RequiredAcks) error {
+	return np.PublishToTopic(ctx, topic, msgType, payload, WithRequiredAcks(acks))
 }
 
 // PublishMessage publishes a notification message with the specified msgType, payload,
 // topic, and logType to Kafka. The message is marshaled from a NotificationMessage DTO
-// and sent synchronously with delivery confirmation.
+// and sent synchronously with delivery confirmation. The configured TopicPrefix is
+// prepended to topic. Options adjust how this single message is published.
 //
-// Returns an error if message creation, marshaling, or sending fails.
-func (np *NotificationProducer) PublishMessage(ctx context.Context, payload interface{}, msgType, topic, logType string) error {
-	notificationMsg, err := dto.NewNotificationMessage(fmt.Sprintf("%s-%d", msgType, time.Now().UnixNano()), msgType, payload)
+// The payload's locale, or the configured DefaultLocale, is written to the locale header,
+// and its ExpiresAt, if set, to the expires_at header so consumers can skip stale messages.
+// Headers stored in ctx (see WithTenant and WithContextHeader) and set with WithHeader
+// are written alongside the standard headers.
+//
+// Returns ErrRateLimited if msgType exceeds its RateLimit with the reject policy,
+// ErrSchemaValidation if the payload does not match the schema registered for
+// msgType, ErrMessageTooLarge if the marshaled message exceeds MaxMessageBytes, or an
+// error if message creation, marshaling, or sending fails. Send errors are wrapped
+// with ErrRetriable or ErrFatal. The whole publish, including Sarama's internal
+// retries, is bounded by ctx and the producer's publish timeout: it returns
+// ErrDeadlineExceeded as soon as the ctx deadline passes, and ErrTimeout when the
+// publish timeout (see WithPublishTimeout) passes first. A ctx that is already done
+// fails immediately, before the message is built. The OnPublish callback, if configured, is invoked
+// with the outcome once the message has been created.
+func (np *NotificationProducer) PublishMessage(ctx context.Context, payload interface{}, msgType, topic, logType string, opts ...PublishOption) error {
+	_, err := np.publish(ctx, payload, msgType, topic, logType, opts)
+	return err
+}
+
+// publish implements PublishMessage, additionally returning where the message was
+// written. Partition and Offset are -1 if the message was not delivered.
+func (np *NotificationProducer) publish(ctx context.Context, payload interface{}, msgType, topic, logType string, opts []PublishOption) (PublishResult, error) {
+	topic = np.config.Topic(topic)
+	result := PublishResult{Topic: topic, Partition: -1, Offset: -1}
+	if ctx.Err() != nil {
+		return result, ctxError(ctx)
+	}
+
+	start := time.Now()
+	ctx, cancel := np.withPublishTimeout(ctx)
+	defer cancel()
+
+	options := newPublishOptions(opts)
+	options.withContextHeaders(ctx)
+
+	id := fmt.Sprintf("%s-%d", msgType, time.Now().UnixNano())
+	if options.messageID != "" {
+		if err := validateMessageID(options.messageID); err != nil {
+			return result, err
+		}
+		id = options.messageID
+	}
+
+	drop, err := np.checkRateLimit(msgType)
+	if drop || err != nil {
+		return result, err
+	}
+
+	locale, err := np.resolveLocale(payload)
 	if err != nil {
-		return fmt.Errorf("failed to create notification message: %w", err)
+		return result, err
+	}
+	options.locale = locale
+	if expiring, ok := payload.(dto.Expiring); ok {
+		options.expiresAt = expiring.MessageExpiry()
 	}
 
-	messageBytes, err := json.Marshal(notificationMsg)
+	notificationMsg, err := dto.NewNotificationMessage(id, msgType, payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return result, fmt.Errorf("%w: failed to create notification message: %w", ErrFatal, err)
+	}
+
+	result, err = np.send(ctx, notificationMsg, topic, logType, options)
+	if np.metrics != nil {
+		np.metrics.RecordPublish(msgType, topic, time.Since(start), err)
+	}
+	if np.onPublish != nil {
+		np.onPublish(*notificationMsg, result, err)
+	}
+	return result, err
+}
+
+// send validates and marshals notificationMsg, then produces it to topic and waits
+// for delivery confirmation. In fire-and-forget mode it only enqueues the message.
+//
+// With WithStoreAndForward, a message that fails with a retriable error is buffered
+// for redelivery instead.
+//
+// Returns the delivery result, with Partition and Offset set to -1 if the message was
+// not delivered, and any validation, marshaling, or send error.
+func (np *NotificationProducer) send(ctx context.Context, notificationMsg *dto.NotificationMessage, topic, logType string, options publishOptions) (PublishResult, error) {
+	result := PublishResult{
+		MessageID: notificationMsg.ID,
+		Topic:     topic,
+		Partition: -1,
+		Offset:    -1,
+	}
+
+	kafkaMsg, err := np.buildKafkaMessage(notificationMsg, topic, options)
+	if err != nil {
+		return result, err
+	}
+
+	if np.async != nil {
+		return result, np.enqueue(ctx, kafkaMsg)
+	}
+
+	result.Partition, result.Offset, err = np.produceAndWait(ctx, kafkaMsg, options.acks, notificationMsg.ID, topic, logType)
+	if err != nil && np.store(kafkaMsg, options.acks, notificationMsg.ID, err) {
+		result.Buffered = true
+		return result, nil
+	}
+	return result, err
+}
+
+// buildKafkaMessage validates and marshals notificationMsg into a Kafka message for
+// topic with the standard headers. With CompactPayload, the payload of
+// notificationMsg is compacted first, with WithTimeFormat its times are converted after
+// schema validation, with WithKeyTransform its keys are then renamed, and with
+// WithPayloadEncryption it is finally encrypted. The record timestamp is the message's CreatedAt,
+// so the Kafka log and the payload agree on when it was created. The entries of the
+// message's Headers, including those set with WithMetadata, are written as Kafka
+// headers too, below the per-call and context headers. The schema_version header
+// defaults to dto.SchemaVersion unless set with WithSchemaVersion. The message key is
+// the idempotency key, if set, or else the key derived by the KeyFunc registered for
+// the message type.
+//
+// Returns ErrSchemaValidation, ErrMessageTooLarge, or a marshaling error.
+func (np *NotificationProducer) buildKafkaMessage(notificationMsg *dto.NotificationMessage, topic string, options publishOptions) (*sarama.ProducerMessage, error) {
+	if notificationMsg.Headers == nil && len(options.metadata) > 0 {
+		notificationMsg.Headers = make(map[string]interface{}, len(options.metadata))
+	}
+	maps.Copy(notificationMsg.Headers, options.metadata)
+
+	key, hasKey := np.messageKey(notificationMsg.Type, notificationMsg.Payload)
+
+	if options.compact {
+		payload, err := compactPayload(notificationMsg.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compact payload: %w", err)
+		}
+		notificationMsg.Payload = payload
+	}
+
+	if err := np.validatePayload(notificationMsg.Type, notificationMsg.Payload); err != nil {
+		return nil, err
+	}
+
+	epochMillis := np.timeFormat == TimeFormatEpochMillis
+	if epochMillis {
+		payload, err := kafkautil.EpochMillisTimes(notificationMsg.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert payload times: %w", err)
+		}
+		notificationMsg.Payload = payload
+	}
+
+	if transform, ok := np.keyTransforms[topic]; ok {
+		payload, err := transformKeys(notificationMsg.Payload, transform)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform payload keys: %w", err)
+		}
+		notificationMsg.Payload = payload
+	}
+
+	encrypted := np.encrypts(notificationMsg.Type)
+	if encrypted {
+		payload, err := kafkautil.EncryptPayload(np.payloadCipher, notificationMsg.ID, notificationMsg.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+		}
+		notificationMsg.Payload = payload
+	}
+
+	messageBytes, err := np.marshalMessage(notificationMsg, !options.compact)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	compressed := np.compress && len(messageBytes) >= np.compressMinBytes
+	if compressed {
+		if messageBytes, err = gzipBytes(messageBytes); err != nil {
+			return nil, fmt.Errorf("failed to compress message: %w", err)
+		}
+	}
+
+	if limit := np.config.MaxMessageBytes; limit > 0 && len(messageBytes) > limit {
+		return nil, fmt.Errorf("%w: %w: %d bytes exceeds limit of %d bytes", ErrFatal, ErrMessageTooLarge, len(messageBytes), limit)
 	}
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: topic,
-		Value: sarama.StringEncoder(messageBytes),
+		Topic:     topic,
+		Value:     sarama.StringEncoder(messageBytes),
+		Timestamp: notificationMsg.CreatedAt, // Keep the log timestamp in step with the payload
 		Headers: []sarama.RecordHeader{
 			{Key: []byte("message_id"), Value: []byte(notificationMsg.ID)},
-			{Key: []byte("type"), Value: []byte(msgType)},
+			{Key: []byte("type"), Value: []byte(notificationMsg.Type)},
 			{Key: []byte("timestamp"), Value: []byte(notificationMsg.CreatedAt.Format(time.RFC3339))},
 		},
 	}
+	if hasKey {
+		kafkaMsg.Key = sarama.StringEncoder(key)
+	}
+	if options.locale != "" {
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte("locale"), Value: []byte(options.locale)})
+	}
+	if compressed {
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte("content-encoding"), Value: []byte("gzip")})
+	}
+	if encrypted {
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte(HeaderEncrypted), Value: []byte("true")})
+	}
+	if epochMillis {
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte(HeaderTimeFormat), Value: []byte(kafkautil.TimeFormatEpochMillis)})
+	}
+	if options.idemKey != "" {
+		kafkaMsg.Key = sarama.StringEncoder(options.idemKey)
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte("idempotency_key"), Value: []byte(options.idemKey)})
+	}
+	if options.expiresAt != nil {
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte("expires_at"), Value: []byte(options.expiresAt.Format(time.RFC3339))})
+	}
+	custom := make(map[string]string, len(notificationMsg.Headers)+len(options.headers))
+	for key, value := range notificationMsg.Headers {
+		custom[key] = headerString(value)
+	}
+	maps.Copy(custom, options.headers)
+	if _, ok := custom[HeaderSchemaVersion]; !ok {
+		custom[HeaderSchemaVersion] = strconv.Itoa(dto.SchemaVersion)
+	}
+	for _, key := range slices.Sorted(maps.Keys(custom)) {
+		if reservedHeaders[key] {
+			continue
+		}
+		kafkaMsg.Headers = append(kafkaMsg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(custom[key])})
+	}
+	return kafkaMsg, nil
+}
+
+// headerString renders a NotificationMessage header value as a Kafka header value:
+// strings and byte slices as is, fmt.Stringers by their String method, and anything
+// else as JSON.
+func headerString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// requirePayload rejects a zero-value message DTO, which would publish an empty
+// notification.
+//
+// Returns an error wrapping ErrFatal and dto.ErrEmptyPayload if payload is the zero
+// value of its type.
+func requirePayload(payload interface{}) error {
+	if payload == nil || reflect.ValueOf(payload).IsZero() {
+		return fmt.Errorf("%w: %w: %T has no fields set", ErrFatal, dto.ErrEmptyPayload, payload)
+	}
+	return nil
+}
+
+// resolveLocale returns the canonical locale for payload, falling back to the
+// configured DefaultLocale when the payload does not set one.
+//
+// Returns an error wrapping ErrFatal if the locale is not a valid BCP 47 tag.
+func (np *NotificationProducer) resolveLocale(payload interface{}) (string, error) {
+	locale := np.config.DefaultLocale
+	if localized, ok := payload.(dto.Localized); ok && localized.MessageLocale() != "" {
+		locale = localized.MessageLocale()
+	}
+	if locale == "" {
+		return "", nil
+	}
 
-	return np.produceAndWait(ctx, kafkaMsg, notificationMsg.ID, topic, logType)
+	canonical, err := dto.ParseLocale(locale)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrFatal, err)
+	}
+	return canonical, nil
 }
 
 // produceAndWait sends the Kafka message asynchronously but waits for delivery confirmation,
-// respecting context cancellation or a timeout of 30 seconds.
+// respecting cancellation and the publish deadline of ctx. It returns partition and
+// offset on success.
 //
-// Returns an error if the message fails to send or if the context is cancelled or times out.
-func (np *NotificationProducer) produceAndWait(ctx context.Context, kafkaMsg *sarama.ProducerMessage, messageID, topic, logType string) error {
-	done := make(chan error, 1)
+// Returns a send error wrapped with ErrRetriable or ErrFatal, or the error from
+// ctxError if ctx is done first.
+func (np *NotificationProducer) produceAndWait(ctx context.Context, kafkaMsg *sarama.ProducerMessage, acks sarama.RequiredAcks, messageID, topic, logType string) (int32, int64, error) {
+	type delivery struct {
+		partition int32
+		offset    int64
+		err       error
+	}
+	done := make(chan delivery, 1)
 
 	go func() {
-		partition, offset, err := np.safeSendMessage(kafkaMsg)
+		partition, offset, err := np.safeSendMessage(kafkaMsg, acks)
 		if err != nil {
-			done <- fmt.Errorf("failed to produce message: %w", err)
+			done <- delivery{partition: -1, offset: -1, err: fmt.Errorf("failed to produce message: %w", classifyError(err))}
 			return
 		}
-		np.logger.Infof("%s message published successfully | ID: %s | Topic: %s | Partition: %d | Offset: %d", logType, messageID, topic, partition, offset)
-		done <- nil
+		if np.logSuccesses {
+			np.logger.Infof("%s message published successfully | ID: %s | Topic: %s | Partition: %d | Offset: %d", logType, messageID, topic, partition, offset)
+		}
+		done <- delivery{partition: partition, offset: offset}
 	}()
 
 	select {
-	case err := <-done:
-		return err
+	case d := <-done:
+		return d.partition, d.offset, d.err
 	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(30 * time.Second):
-		return fmt.Errorf("timeout while waiting for message delivery")
+		return -1, -1, ctxError(ctx)
 	}
 }
 
-// safeSendMessage sends the given Kafka message under mutex protection to ensure
-// the producer is not closed while sending. It returns partition and offset on success.
+// safeSendMessage sends the given Kafka message with the given ack level, tracked as
+// in progress so that Close waits for it instead of closing the producer under it. It
+// returns partition and offset on success.
 //
-// Returns an error if the producer is closed.
-func (np *NotificationProducer) safeSendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
-	np.mu.Lock()
-	defer np.mu.Unlock()
+// Returns ErrProducerClosed if the producer is closed.
+func (np *NotificationProducer) safeSendMessage(msg *sarama.ProducerMessage, acks sarama.RequiredAcks) (int32, int64, error) {
+	producer, err := np.beginSend(acks)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer np.sending.Done()
 
+	np.stats.enqueued.Add(1)
+	partition, offset, err := producer.SendMessage(msg)
+	if err != nil {
+		np.stats.failed.Add(1)
+	} else {
+		np.stats.delivered.Add(1)
+	}
+	return partition, offset, err
+}
+
+// beginSend returns the Sarama producer for the given ack level and registers a send
+// in progress, which the caller must end with np.sending.Done. Registering under
+// np.mu orders it before Close, which sets closed under the same lock before waiting.
+// A producer for a new ack level is created without holding np.mu, since connecting
+// to the brokers can take up to the dial and metadata timeouts.
+//
+// Returns ErrProducerClosed if the producer is closed, or an error if the producer
+// for acks cannot be created.
+func (np *NotificationProducer) beginSend(acks sarama.RequiredAcks) (sarama.SyncProducer, error) {
+	np.mu.Lock()
 	if np.closed {
-		return 0, 0, fmt.Errorf("producer is closed")
+		np.mu.Unlock()
+		return nil, ErrProducerClosed
 	}
+	if producer, ok := np.producerFor(acks); ok {
+		np.sending.Add(1)
+		np.mu.Unlock()
+		return producer, nil
+	}
+	np.mu.Unlock()
+
+	created, err := sarama.NewSyncProducer(np.brokers, newProducerConfig(np.config, acks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer for acks=%d: %w", acks, err)
+	}
+
+	np.mu.Lock()
+	producer, ok := np.producerFor(acks)
+	if !np.closed && !ok {
+		np.producers[acks] = created
+		producer = created
+		np.logger.Infof("Created Kafka producer for acks=%d", acks)
+	}
+	closed := np.closed
+	if !closed {
+		np.sending.Add(1)
+	}
+	np.mu.Unlock()
 
-	return np.producer.SendMessage(msg)
+	// Another send registered a producer first, or Close started while connecting.
+	if producer != created {
+		if err := created.Close(); err != nil {
+			np.logger.Errorf("Error closing unused Kafka producer for acks=%d: %v", acks, err)
+		}
+	}
+	if closed {
+		return nil, ErrProducerClosed
+	}
+	return producer, nil
+}
+
+// producerFor returns the Sarama producer for the given ack level, or false if it has
+// not been created yet. The default WaitForAll producer is created by the constructor.
+// It must be called with np.mu held.
+func (np *NotificationProducer) producerFor(acks sarama.RequiredAcks) (sarama.SyncProducer, bool) {
+	if acks == sarama.WaitForAll {
+		return np.producer, true
+	}
+	producer, ok := np.producers[acks]
+	return producer, ok
 }
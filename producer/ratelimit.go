@@ -0,0 +1,65 @@
+package producer
+
+import (
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitPolicy selects what happens to a publish that exceeds its RateLimit.
+type RateLimitPolicy int
+
+const (
+	// RateLimitReject fails the publish with ErrRateLimited. This is the default.
+	RateLimitReject RateLimitPolicy = iota
+	// RateLimitDrop logs and discards the message, and the publish returns nil.
+	RateLimitDrop
+)
+
+// RateLimit caps the publish rate of a single message type.
+type RateLimit struct {
+	PerSecond float64         // Sustained messages per second
+	Burst     int             // Messages allowed above the sustained rate in a burst; at least 1
+	Policy    RateLimitPolicy // What to do with messages over the limit
+}
+
+// WithRateLimits caps the publish rate of each message type in limits independently,
+// keyed by msgType (e.g., "in_app"), so a noisy type cannot flood its topic. Types
+// without an entry are not limited. Limits apply per producer instance.
+func WithRateLimits(limits map[string]RateLimit) Option {
+	return func(np *NotificationProducer) {
+		np.rateLimits = make(map[string]*typeLimiter, len(limits))
+		for msgType, limit := range limits {
+			burst := limit.Burst
+			if burst < 1 {
+				burst = 1
+			}
+			np.rateLimits[msgType] = &typeLimiter{
+				limiter: rate.NewLimiter(rate.Limit(limit.PerSecond), burst),
+				policy:  limit.Policy,
+			}
+		}
+	}
+}
+
+// typeLimiter enforces the RateLimit of a single message type.
+type typeLimiter struct {
+	limiter *rate.Limiter
+	policy  RateLimitPolicy
+}
+
+// checkRateLimit reports whether a message of msgType may be published now.
+//
+// Returns drop true if the message should be silently discarded, or an error
+// wrapping ErrRateLimited if it should be rejected.
+func (np *NotificationProducer) checkRateLimit(msgType string) (drop bool, err error) {
+	tl, ok := np.rateLimits[msgType]
+	if !ok || tl.limiter.Allow() {
+		return false, nil
+	}
+	if tl.policy == RateLimitDrop {
+		np.logger.Errorf("Rate limit exceeded, dropping message | Type: %s", msgType)
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: %w: %s", ErrRetriable, ErrRateLimited, msgType)
+}
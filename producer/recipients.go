@@ -0,0 +1,66 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dawit-go/notification-kafka-lib/dto"
+)
+
+// PerRecipient publishes an email with several recipients as one message per
+// recipient, each with its own message ID, instead of a single message addressed to
+// all of them. Use it when the email provider needs per-recipient tracking or
+// unsubscribe handling; note that it multiplies the messages, and so the sends, billed
+// downstream.
+//
+// CC and BCC contacts are kept only on the first recipient's message, so they still
+// receive a single copy. An idempotency key set with WithIdempotencyKey is suffixed
+// with each recipient's address, so that consumer deduplication does not collapse
+// the messages into one; a message ID set with WithMessageID is suffixed the same way.
+// It applies to PublishEmailMessage and PublishSendEmailRequest.
+func PerRecipient() PublishOption {
+	return func(o *publishOptions) {
+		o.perRecipient = true
+	}
+}
+
+// publishPerRecipient publishes emailMsg to topic once for each of its recipients.
+//
+// Returns the result of the first recipient's message, and nil if every message was
+// published, or an error joining each failure annotated with its recipient.
+func (np *NotificationProducer) publishPerRecipient(ctx context.Context, emailMsg dto.EmailKafkaMessage, topic string, opts []PublishOption) (PublishResult, error) {
+	options := newPublishOptions(opts)
+
+	var first PublishResult
+	var errs []error
+	for i, recipient := range emailMsg.Recipients {
+		single := emailMsg
+		single.Recipients = []dto.EmailContact{recipient}
+		if i > 0 {
+			single.CC = nil
+			single.BCC = nil
+		}
+
+		msgOpts := opts
+		if options.idemKey != "" {
+			msgOpts = append(slices.Clip(msgOpts), WithIdempotencyKey(options.idemKey+":"+recipient.Email))
+		}
+		if options.messageID != "" {
+			msgOpts = append(slices.Clip(msgOpts), WithMessageID(options.messageID+":"+recipient.Email))
+		}
+
+		result, err := np.publish(ctx, single, "email", topic, "Email", msgOpts)
+		if i == 0 {
+			first = result
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recipient %s: %w", recipient.Email, err))
+		}
+	}
+	if len(errs) == 0 {
+		return first, nil
+	}
+	return first, fmt.Errorf("%d of %d recipient messages failed: %w", len(errs), len(emailMsg.Recipients), errors.Join(errs...))
+}
@@ -0,0 +1,61 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ErrSchemaValidation is returned when a payload does not match the JSON schema
+// registered for its message type.
+var ErrSchemaValidation = errors.New("payload failed schema validation")
+
+// RegisterSchema compiles a JSON schema and registers it for msgType. Every payload
+// subsequently published with that msgType is validated against it before sending.
+// Registering a schema for a msgType that already has one replaces it.
+//
+// Returns an error if the schema cannot be compiled.
+func (np *NotificationProducer) RegisterSchema(msgType, schema string) error {
+	compiled, err := jsonschema.CompileString(msgType+".schema.json", schema)
+	if err != nil {
+		return fmt.Errorf("failed to compile schema for %s: %w", msgType, err)
+	}
+
+	np.schemaMu.Lock()
+	defer np.schemaMu.Unlock()
+
+	if np.schemas == nil {
+		np.schemas = make(map[string]*jsonschema.Schema)
+	}
+	np.schemas[msgType] = compiled
+	return nil
+}
+
+// validatePayload validates the marshaled payload against the schema registered for
+// msgType. Message types without a registered schema are not validated.
+//
+// Returns an error wrapping ErrSchemaValidation if the payload does not match.
+func (np *NotificationProducer) validatePayload(msgType string, payload json.RawMessage) error {
+	np.schemaMu.RLock()
+	schema, ok := np.schemas[msgType]
+	np.schemaMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("failed to decode payload for schema validation: %w", err)
+	}
+
+	if err := schema.Validate(value); err != nil {
+		return fmt.Errorf("%w: %w: %s payload: %w", ErrFatal, ErrSchemaValidation, msgType, err)
+	}
+	return nil
+}
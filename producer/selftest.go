@@ -0,0 +1,52 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HeaderSelfTest marks the messages published by SelfTest. NotificationConsumer skips
+// them; other consumers should ignore messages where it is "true".
+const HeaderSelfTest = "self_test"
+
+// selfTestType is the message type of the messages published by SelfTest.
+const selfTestType = "self_test"
+
+// SelfTest publishes a benign test message to each configured notification topic and
+// waits for delivery, to verify connectivity and write permissions after a deploy.
+// Each message has type "self_test" and carries the HeaderSelfTest header so that
+// consumers can ignore it. In fire-and-forget mode delivery is not confirmed, only
+// enqueuing.
+//
+// Returns the PublishResult of every topic, keyed by topic name including the
+// TopicPrefix, together with an error joining the failures of any topics.
+func (np *NotificationProducer) SelfTest(ctx context.Context) (map[string]PublishResult, error) {
+	topics := []string{
+		np.config.SMSTopic,
+		np.config.EmailTopic,
+		np.config.InAppTopic,
+		np.config.PushTopic,
+	}
+	payload := map[string]string{
+		"message":  "notification self-test, safe to ignore",
+		"sent_at":  time.Now().UTC().Format(time.RFC3339),
+		"producer": np.config.ClientID,
+	}
+	opts := []PublishOption{WithHeader(HeaderSelfTest, "true")}
+
+	results := make(map[string]PublishResult, len(topics))
+	var errs []error
+	for _, topic := range topics {
+		result, err := np.publish(ctx, payload, selfTestType, topic, "Self-Test", opts)
+		results[result.Topic] = result
+		if err != nil {
+			errs = append(errs, fmt.Errorf("topic %s: %w", result.Topic, err))
+		}
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("self-test failed for %d of %d topics: %w", len(errs), len(topics), errors.Join(errs...))
+	}
+	return results, nil
+}
@@ -0,0 +1,64 @@
+package producer
+
+import "sync/atomic"
+
+// Stats is a point-in-time snapshot of the producer's delivery counters.
+type Stats struct {
+	Enqueued    int64 // Messages handed to Sarama for delivery
+	Delivered   int64 // Messages acknowledged by the brokers
+	Failed      int64 // Messages that failed for good, counted once however many attempts they took
+	InFlight    int64 // Messages enqueued but not yet delivered or failed; excludes untracked fire-and-forget messages
+	BufferDepth int   // Messages waiting in the fire-and-forget queue and input buffer; zero otherwise
+	Overflowed  int64 // Fire-and-forget messages dropped by QueueDropOldest or rejected by QueueError
+	Buffered    int   // Messages awaiting redelivery by WithStoreAndForward
+	Dropped     int64 // Messages WithStoreAndForward dropped: over a full buffer, on a fatal error, or on Close
+	Retries     int64 // Redelivery attempts made by WithStoreAndForward, successful or not
+}
+
+// producerStats holds the counters behind Stats.
+type producerStats struct {
+	enqueued  atomic.Int64
+	delivered atomic.Int64
+	failed    atomic.Int64
+
+	// Fire-and-forget messages enqueued without WithDeliveryReports, whose successes
+	// are not reported, and those of them that failed.
+	untracked       atomic.Int64
+	untrackedFailed atomic.Int64
+
+	overflowed atomic.Int64
+	retries    atomic.Int64
+}
+
+// Stats returns a snapshot of the delivery counters, covering both synchronous and
+// fire-and-forget publishes. It only reads atomic counters, so it is cheap enough to
+// poll frequently, e.g. to feed dashboards or detect backpressure.
+//
+// Without WithDeliveryReports, fire-and-forget deliveries are not reported by
+// Sarama, so they are not counted as Delivered and are left out of InFlight;
+// BufferDepth shows their backlog instead.
+func (np *NotificationProducer) Stats() Stats {
+	// Load the outcomes before what was enqueued so InFlight is never negative.
+	delivered := np.stats.delivered.Load()
+	failed := np.stats.failed.Load()
+	untrackedFailed := np.stats.untrackedFailed.Load()
+	untracked := np.stats.untracked.Load()
+	enqueued := np.stats.enqueued.Load()
+
+	stats := Stats{
+		Enqueued:  enqueued,
+		Delivered: delivered,
+		Failed:    failed,
+		InFlight:  (enqueued - untracked) - delivered - (failed - untrackedFailed),
+	}
+	if np.async != nil {
+		stats.BufferDepth = len(np.queue) + len(np.async.Input())
+		stats.Overflowed = np.stats.overflowed.Load()
+	}
+	if np.buffer != nil {
+		stats.Buffered = np.buffer.len()
+		stats.Dropped = np.buffer.dropped.Load()
+		stats.Retries = np.stats.retries.Load()
+	}
+	return stats
+}
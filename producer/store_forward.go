@@ -0,0 +1,250 @@
+package producer
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// BufferPolicy selects what happens to a message that fails transiently while the
+// store-and-forward buffer is full.
+type BufferPolicy int
+
+const (
+	// BufferDropNewest keeps the buffered messages and fails the new publish with its
+	// original error. This is the default.
+	BufferDropNewest BufferPolicy = iota
+	// BufferDropOldest discards the oldest buffered message to make room for the new
+	// one, whose publish then succeeds.
+	BufferDropOldest
+)
+
+// Backoff between redelivery attempts of the store-and-forward buffer.
+const (
+	forwardInitialBackoff = time.Second
+	forwardMaxBackoff     = 30 * time.Second
+)
+
+// WithStoreAndForward smooths over brief broker outages: a synchronous publish that
+// fails with a retriable error (see ErrRetriable) is kept in an in-memory buffer of up
+// to maxMessages messages and redelivered in the background, in order, with
+// exponential backoff, and the publish returns nil with PublishResult.Buffered set.
+// Fatal errors, timeouts, and cancelled publishes still fail as before. When the
+// buffer is full, policy decides which message is dropped. Stats reports the
+// buffered and dropped counts.
+//
+// The buffer lives in process memory, so buffered messages are lost if the process
+// exits, and Close drops the messages still buffered. Use it only for non-critical
+// notifications. It does not apply to PublishEmailBatch or fire-and-forget mode.
+func WithStoreAndForward(maxMessages int, policy BufferPolicy) Option {
+	return func(np *NotificationProducer) {
+		if maxMessages < 1 {
+			maxMessages = 1
+		}
+		np.buffer = &forwardBuffer{
+			max:    maxMessages,
+			policy: policy,
+			wake:   make(chan struct{}, 1),
+			stop:   make(chan struct{}),
+		}
+	}
+}
+
+// bufferedMessage is a message waiting in the store-and-forward buffer.
+type bufferedMessage struct {
+	msg  *sarama.ProducerMessage
+	acks sarama.RequiredAcks
+	id   string // Message ID, for logging
+}
+
+// forwardBuffer is the bounded FIFO of messages awaiting redelivery.
+type forwardBuffer struct {
+	mu       sync.Mutex
+	items    []*bufferedMessage
+	max      int
+	policy   BufferPolicy
+	wake     chan struct{} // Signaled when a message is added
+	stop     chan struct{} // Closed when the producer closes
+	stopOnce sync.Once
+	dropped  atomic.Int64
+}
+
+// add buffers msg for redelivery, evicting the oldest message if the buffer is full
+// and the policy is BufferDropOldest.
+//
+// Returns whether the message was buffered, which it is not if the buffer is full or
+// closed, and whether an older message was evicted for it.
+func (b *forwardBuffer) add(msg *bufferedMessage) (added, evicted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-b.stop:
+		return false, false
+	default:
+	}
+	if len(b.items) >= b.max {
+		if b.policy != BufferDropOldest {
+			b.dropped.Add(1)
+			return false, false
+		}
+		b.items = b.items[1:]
+		b.dropped.Add(1)
+		evicted = true
+	}
+	b.items = append(b.items, msg)
+
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return true, evicted
+}
+
+// peek returns the oldest buffered message, or false if the buffer is empty.
+func (b *forwardBuffer) peek() (*bufferedMessage, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.items) == 0 {
+		return nil, false
+	}
+	return b.items[0], true
+}
+
+// remove removes msg if it is still the oldest buffered message; it may already have
+// been evicted by BufferDropOldest.
+//
+// Returns whether msg was still buffered.
+func (b *forwardBuffer) remove(msg *bufferedMessage) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.items) == 0 || b.items[0] != msg {
+		return false
+	}
+	b.items = b.items[1:]
+	return true
+}
+
+// len returns the number of buffered messages.
+func (b *forwardBuffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.items)
+}
+
+// close stops redelivery and discards the buffered messages.
+//
+// Returns the number of messages discarded.
+func (b *forwardBuffer) close() int {
+	b.stopOnce.Do(func() { close(b.stop) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	discarded := len(b.items)
+	b.items = nil
+	b.dropped.Add(int64(discarded))
+	return discarded
+}
+
+// store buffers kafkaMsg after a publish failed with err, if store-and-forward is
+// enabled and err is a retriable send error. A buffered message is not counted as
+// Failed in Stats unless it is dropped before it is redelivered, so that each message
+// counts as failed at most once however many attempts it takes.
+//
+// Returns whether the message was buffered.
+func (np *NotificationProducer) store(kafkaMsg *sarama.ProducerMessage, acks sarama.RequiredAcks, messageID string, err error) bool {
+	if np.buffer == nil || !errors.Is(err, ErrRetriable) {
+		return false
+	}
+
+	// Resend a copy, since Sarama keeps internal state on messages it has sent.
+	msg := &bufferedMessage{
+		msg: &sarama.ProducerMessage{
+			Topic:     kafkaMsg.Topic,
+			Key:       kafkaMsg.Key,
+			Value:     kafkaMsg.Value,
+			Headers:   kafkaMsg.Headers,
+			Timestamp: kafkaMsg.Timestamp,
+		},
+		acks: acks,
+		id:   messageID,
+	}
+	added, evicted := np.buffer.add(msg)
+	if !added {
+		np.logger.Errorf("Store-and-forward buffer full, message not buffered | ID: %s | Topic: %s", messageID, kafkaMsg.Topic)
+		return false
+	}
+	// The failed attempt was counted by safeSendMessage; the message is pending again
+	// until it is redelivered, and the message evicted for it has now failed for good.
+	np.stats.failed.Add(-1)
+	if evicted {
+		np.stats.failed.Add(1)
+	}
+	np.logger.Errorf("Message buffered for redelivery | ID: %s | Topic: %s | Error: %v", messageID, kafkaMsg.Topic, err)
+	return true
+}
+
+// resend makes one redelivery attempt of a buffered message. The attempt is counted
+// in Stats as a retry, not as a new message.
+//
+// Returns ErrProducerClosed if the producer is closed, or the send error.
+func (np *NotificationProducer) resend(item *bufferedMessage) error {
+	producer, err := np.beginSend(item.acks)
+	if err != nil {
+		return err
+	}
+	defer np.sending.Done()
+
+	np.stats.retries.Add(1)
+	_, _, err = producer.SendMessage(item.msg)
+	return err
+}
+
+// forward redelivers buffered messages in order until the producer is closed,
+// backing off exponentially while the brokers are unreachable. Messages that fail
+// with a fatal error are dropped. A message evicted by BufferDropOldest while it was
+// being resent was already counted as failed and dropped by store, so its outcome is
+// not counted again.
+func (np *NotificationProducer) forward() {
+	backoff := forwardInitialBackoff
+	for {
+		item, ok := np.buffer.peek()
+		if !ok {
+			select {
+			case <-np.buffer.wake:
+				continue
+			case <-np.buffer.stop:
+				return
+			}
+		}
+
+		err := np.resend(item)
+		switch {
+		case err == nil:
+			backoff = forwardInitialBackoff
+			if np.buffer.remove(item) {
+				np.stats.delivered.Add(1)
+				np.logger.Infof("Buffered message redelivered | ID: %s | Topic: %s", item.id, item.msg.Topic)
+			}
+		case errors.Is(err, ErrProducerClosed):
+			return
+		case !isRetriableSendError(err):
+			if !np.buffer.remove(item) {
+				continue
+			}
+			np.buffer.dropped.Add(1)
+			np.stats.failed.Add(1)
+			np.logger.Errorf("Dropping buffered message after fatal error | ID: %s | Topic: %s | Error: %v", item.id, item.msg.Topic, err)
+		default:
+			select {
+			case <-time.After(backoff):
+			case <-np.buffer.stop:
+				return
+			}
+			backoff = min(backoff*2, forwardMaxBackoff)
+		}
+	}
+}
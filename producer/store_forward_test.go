@@ -0,0 +1,102 @@
+package producer
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+// gatedProducer is a sarama.SyncProducer that runs gate with the number of each send
+// before passing it to the mock, outside the mock's lock, so that a send can be held
+// in flight while others go through.
+type gatedProducer struct {
+	*mocks.SyncProducer
+	sends atomic.Int32
+	gate  func(send int32)
+}
+
+func (p *gatedProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.gate(p.sends.Add(1))
+	return p.SyncProducer.SendMessage(msg)
+}
+
+func TestForwardSkipsMessageEvictedDuringResend(t *testing.T) {
+	tests := []struct {
+		name      string
+		resendErr error // Outcome of the evicted message's resend, or nil for success
+	}{
+		{name: "resend succeeds", resendErr: nil},
+		{name: "resend fails fatally", resendErr: sarama.ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewSyncProducer(t, mocks.NewTestConfig())
+			// Sends in mock order: A fails, B fails while A's resend is held in flight,
+			// then A's resend completes and B's resend succeeds.
+			mock.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+			mock.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+			if tt.resendErr != nil {
+				mock.ExpectSendMessageAndFail(tt.resendErr)
+			} else {
+				mock.ExpectSendMessageAndSucceed()
+			}
+			mock.ExpectSendMessageAndSucceed()
+
+			inFlight := make(chan struct{})
+			release := make(chan struct{})
+			producer := &gatedProducer{SyncProducer: mock, gate: func(send int32) {
+				if send == 2 { // The first resend, of A
+					close(inFlight)
+					<-release
+				}
+			}}
+
+			np := &NotificationProducer{
+				producer:  producer,
+				producers: make(map[sarama.RequiredAcks]sarama.SyncProducer),
+				logger:    NopLogger(),
+			}
+			WithStoreAndForward(1, BufferDropOldest)(np)
+			go np.forward()
+			defer np.buffer.close()
+
+			sendAndStore := func(id string) {
+				msg := &sarama.ProducerMessage{Topic: "notifications", Value: sarama.StringEncoder(id)}
+				_, _, err := np.safeSendMessage(msg, sarama.WaitForAll)
+				if err == nil {
+					t.Fatalf("send of %s succeeded, want failure", id)
+				}
+				if !np.store(msg, sarama.WaitForAll, id, fmt.Errorf("%w: %w", ErrRetriable, err)) {
+					t.Fatalf("message %s was not buffered", id)
+				}
+			}
+
+			sendAndStore("a")
+			<-inFlight
+			sendAndStore("b") // Evicts A, whose resend is in flight
+			close(release)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for np.Stats().Retries < 2 || np.Stats().Buffered > 0 {
+				if time.Now().After(deadline) {
+					t.Fatalf("buffer not drained, stats %+v", np.Stats())
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			// A counts once, as failed and dropped when it was evicted; B was redelivered.
+			want := Stats{Enqueued: 2, Delivered: 1, Failed: 1, InFlight: 0, Dropped: 1, Retries: 2}
+			if got := np.Stats(); got != want {
+				t.Fatalf("Stats() = %+v, want %+v", got, want)
+			}
+			if err := mock.Close(); err != nil {
+				t.Fatalf("closing mock producer: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package producer
+
+import (
+	"github.com/dawit-go/notification-kafka-lib/dto"
+	"github.com/dawit-go/notification-kafka-lib/internal/kafkautil"
+)
+
+// HeaderTimeFormat is set to "epoch_millis" on messages published with
+// TimeFormatEpochMillis.
+const HeaderTimeFormat = kafkautil.HeaderTimeFormat
+
+// TimeFormat selects how the producer encodes times in message JSON.
+type TimeFormat int
+
+const (
+	// TimeFormatRFC3339 encodes times as RFC 3339 strings, Go's default. This is the
+	// default.
+	TimeFormatRFC3339 TimeFormat = iota
+	// TimeFormatEpochMillis encodes times as Unix epoch milliseconds, for consumers
+	// outside Go that do not parse RFC 3339.
+	TimeFormatEpochMillis
+)
+
+// WithTimeFormat sets how times are encoded in published messages. With
+// TimeFormatEpochMillis, the envelope's created_at and the payload's top-level
+// created_at and expires_at are written as Unix epoch milliseconds, and the message
+// gets a time_format: epoch_millis header. Other payload fields, including those
+// nested in Metadata or TransactionDetails, are left as they are. Consumers in this
+// library convert the payload fields back to RFC 3339 transparently, so handlers
+// unmarshal them into time.Time as before.
+//
+// Payload times are converted after schema validation and before key transforms.
+func WithTimeFormat(format TimeFormat) Option {
+	return func(np *NotificationProducer) {
+		np.timeFormat = format
+	}
+}
+
+// epochMillisMessage is a NotificationMessage whose CreatedAt marshals as Unix epoch
+// milliseconds, shadowing the embedded field.
+type epochMillisMessage struct {
+	*dto.NotificationMessage
+	CreatedAt int64 `json:"created_at"`
+}
+
+// marshalMessage marshals notificationMsg with its CreatedAt in the configured time
+// format, escaping HTML characters in strings only if escapeHTML is set.
+func (np *NotificationProducer) marshalMessage(notificationMsg *dto.NotificationMessage, escapeHTML bool) ([]byte, error) {
+	if np.timeFormat != TimeFormatEpochMillis {
+		return encodeJSON(notificationMsg, escapeHTML)
+	}
+	return encodeJSON(epochMillisMessage{
+		NotificationMessage: notificationMsg,
+		CreatedAt:           notificationMsg.CreatedAt.UnixMilli(),
+	}, escapeHTML)
+}
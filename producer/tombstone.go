@@ -0,0 +1,66 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrNotCompacted is returned by PublishTombstone for a topic that was not registered
+// with WithCompactedTopics.
+var ErrNotCompacted = errors.New("topic is not a compacted topic")
+
+// WithCompactedTopics registers topics as log-compacted, such as an in-app topic keyed
+// by notification, so that PublishTombstone may clear keys on them. The configured
+// TopicPrefix is prepended to each topic. The topics must also be created with
+// cleanup.policy=compact for tombstones to remove anything.
+func WithCompactedTopics(topics ...string) Option {
+	return func(np *NotificationProducer) {
+		if np.compactedTopics == nil {
+			np.compactedTopics = make(map[string]bool, len(topics))
+		}
+		for _, topic := range topics {
+			np.compactedTopics[np.config.Topic(topic)] = true
+		}
+	}
+}
+
+// PublishTombstone publishes a tombstone, a record with key and a nil value, to topic,
+// so that log compaction removes every earlier message with key, for example to clear
+// an in-app notification the user dismissed. The configured TopicPrefix is prepended to
+// topic. It waits for delivery confirmation even in fire-and-forget mode. Consumers in
+// this library skip tombstones instead of handling them.
+//
+// Returns ErrNotCompacted, wrapped with ErrFatal, if topic was not registered with
+// WithCompactedTopics or key is empty, or the delivery error.
+func (np *NotificationProducer) PublishTombstone(ctx context.Context, topic, key string) error {
+	if ctx.Err() != nil {
+		return ctxError(ctx)
+	}
+
+	topic = np.config.Topic(topic)
+	if !np.compactedTopics[topic] {
+		return fmt.Errorf("%w: %w: %s", ErrFatal, ErrNotCompacted, topic)
+	}
+	if key == "" {
+		return fmt.Errorf("%w: tombstone key is required", ErrFatal)
+	}
+
+	start := time.Now()
+	ctx, cancel := np.withPublishTimeout(ctx)
+	defer cancel()
+
+	kafkaMsg := &sarama.ProducerMessage{
+		Topic:     topic,
+		Key:       sarama.StringEncoder(key),
+		Timestamp: start,
+	}
+	_, _, err := np.produceAndWait(ctx, kafkaMsg, sarama.WaitForAll, key, topic, "Tombstone")
+	if np.metrics != nil {
+		np.metrics.RecordPublish("tombstone", topic, time.Since(start), err)
+	}
+	return err
+}